@@ -0,0 +1,158 @@
+package vfsgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Budget limits the size of the content stored in the generated code.
+// Sizes are stored sizes, after transforms and compression.
+// The zero Budget enforces no limits.
+type Budget struct {
+	// MaxTotalSize is the maximum total stored size of all files.
+	// If zero, it's not enforced.
+	MaxTotalSize int64
+
+	// MaxFileSize is the maximum stored size of each file.
+	// If zero, it's not enforced.
+	MaxFileSize int64
+
+	// Limits are maximum total stored sizes of the files that match patterns.
+	// Each limit is enforced independently, and a file can count toward several.
+	Limits []BudgetLimit
+}
+
+// BudgetLimit is the maximum total stored size of the files that match Pattern.
+type BudgetLimit struct {
+	// Pattern is in the same format as Options.Include, but it's matched against
+	// paths in the generated filesystem, after StripPrefix and MountPrefix are applied.
+	// For example, "*.mp4" or "/vendor/**".
+	Pattern string
+
+	// MaxSize is the maximum total stored size of the matching files.
+	// Unlike the limits in Budget, it's enforced when zero, which makes
+	// generation fail if any matching file has content.
+	MaxSize int64
+}
+
+// BudgetError is the error returned by Generate when the generated code exceeds
+// Options.Budget. Nothing is written in that case.
+type BudgetError struct {
+	Violations []BudgetViolation
+}
+
+// BudgetViolation is a budget limit that's exceeded.
+type BudgetViolation struct {
+	// Limit describes the exceeded limit: "total size", "file size",
+	// or the pattern of a BudgetLimit.
+	Limit   string
+	MaxSize int64
+	Size    int64        // Stored size that exceeds MaxSize, with content shared by files counted once.
+	Files   []BudgetFile // Files that count toward Size, largest first.
+}
+
+// BudgetFile is a file that counts toward a BudgetViolation.
+type BudgetFile struct {
+	Path string
+	Size int64 // Stored size.
+}
+
+// maxBudgetErrorFiles is the maximum number of files listed
+// for each violation in the message of a BudgetError.
+const maxBudgetErrorFiles = 10
+
+func (e *BudgetError) Error() string {
+	var buf strings.Builder
+	fmt.Fprint(&buf, "generated code exceeds budget:")
+	for _, v := range e.Violations {
+		fmt.Fprintf(&buf, "\n%s: %d bytes, exceeds %d bytes", v.Limit, v.Size, v.MaxSize)
+		for i, f := range v.Files {
+			if i == maxBudgetErrorFiles {
+				fmt.Fprintf(&buf, "\n\t... and %d more files", len(v.Files)-i)
+				break
+			}
+			fmt.Fprintf(&buf, "\n\t%s: %d bytes", f.Path, f.Size)
+		}
+	}
+	return buf.String()
+}
+
+// validate returns an error if a pattern in b is invalid.
+func (b Budget) validate() error {
+	for _, l := range b.Limits {
+		if err := validatePattern(l.Pattern); err != nil {
+			return fmt.Errorf("budget: %v", err)
+		}
+	}
+	return nil
+}
+
+// check returns a *BudgetError if the files in toc exceed budget b, or nil otherwise.
+// Content shared by files counts once toward each total size.
+func (b Budget) check(toc TOC) error {
+	files := make([]budgetFile, len(toc.Files))
+	for i, f := range toc.Files {
+		files[i] = budgetFile{BudgetFile{Path: f.Path, Size: f.StoredSize}, f.SharedContent}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+
+	var violations []BudgetViolation
+	if b.MaxTotalSize > 0 {
+		if total := sumSizes(files); total > b.MaxTotalSize {
+			violations = append(violations, BudgetViolation{Limit: "total size", MaxSize: b.MaxTotalSize, Size: total, Files: exported(files)})
+		}
+	}
+	if b.MaxFileSize > 0 {
+		for _, f := range files {
+			if f.Size > b.MaxFileSize {
+				violations = append(violations, BudgetViolation{Limit: "file size", MaxSize: b.MaxFileSize, Size: f.Size, Files: []BudgetFile{f.BudgetFile}})
+			}
+		}
+	}
+	for _, l := range b.Limits {
+		var matched []budgetFile
+		for _, f := range files {
+			if matchPattern(l.Pattern, strings.TrimPrefix(f.Path, "/")) {
+				matched = append(matched, f)
+			}
+		}
+		if total := sumSizes(matched); total > l.MaxSize {
+			violations = append(violations, BudgetViolation{Limit: l.Pattern, MaxSize: l.MaxSize, Size: total, Files: exported(matched)})
+		}
+	}
+	if len(violations) > 0 {
+		return &BudgetError{Violations: violations}
+	}
+	return nil
+}
+
+// budgetFile is a file whose size is checked against a budget.
+type budgetFile struct {
+	BudgetFile
+	sharedContent string // Name of the variable that holds the content, if it's shared.
+}
+
+// sumSizes returns the total size of files, counting shared content once.
+func sumSizes(files []budgetFile) int64 {
+	var n int64
+	counted := make(map[string]bool)
+	for _, f := range files {
+		if f.sharedContent != "" {
+			if counted[f.sharedContent] {
+				continue
+			}
+			counted[f.sharedContent] = true
+		}
+		n += f.Size
+	}
+	return n
+}
+
+func exported(files []budgetFile) []BudgetFile {
+	bfs := make([]BudgetFile, len(files))
+	for i, f := range files {
+		bfs[i] = f.BudgetFile
+	}
+	return bfs
+}
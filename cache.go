@@ -0,0 +1,140 @@
+package vfsgen
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"text/template"
+)
+
+// cacheVersion is part of cache keys, and needs to be changed
+// whenever the format of cache entries changes.
+const cacheVersion = 1
+
+// writeCachedFileInfo writes CompressedFileInfo, or the compressed content with
+// other templates named name, like writeCompressedFileInfo, but reuses the gzip
+// compressed content from the cache in directory dir if it's there, and adds it
+// otherwise. sum is the SHA-256 hash of the content that's read from r.
+// It returns errCompressedNotSmaller if compressed file is not smaller than original.
+//
+// Cache entries are keyed by a hash of the content, level and the Go version.
+// Compressed output is only stable for a given Go release, so including the version
+// makes cached results the same as ones computed by the running program.
+func writeCachedFileInfo(w io.Writer, t *template.Template, name string, file *FileInfo, r io.Reader, sum [sha256.Size]byte, dir string, level int) error {
+	key := sha256.Sum256([]byte(fmt.Sprintf("vfsgen cache v%d %s gzip level %d content %x", cacheVersion, runtime.Version(), level, sum)))
+	hexKey := hex.EncodeToString(key[:])
+	path := filepath.Join(dir, hexKey[:2], hexKey)
+
+	entry, err := openCacheEntry(path, sum, file.UncompressedSize)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		defer entry.Close()
+		return writeStoredFileInfo(w, t, name, file, entry)
+	}
+
+	// Compress the content into the output and a new cache entry at the same time.
+	// The entry is written to a temporary file that's then renamed, so that
+	// concurrent generations never see a partial entry.
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // Fails harmlessly once the entry is renamed.
+	err = writeCompressedFileInfo(w, t, name, file, r, level, f)
+	if err == errCompressedNotSmaller {
+		// An empty entry means compressed content is not smaller.
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return err
+		}
+	} else if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return err // nil or errCompressedNotSmaller.
+}
+
+// openCacheEntry opens the cache entry at path for content with SHA-256 hash sum and
+// size uncompressedSize, positioned at its start. It returns a nil file if there's
+// no entry, or if it's not valid, such as when it's been truncated or otherwise
+// damaged, so that it's replaced rather than written into the generated code.
+// A valid entry is empty, or the gzip compressed content, smaller than it.
+func openCacheEntry(path string, sum [sha256.Size]byte, uncompressedSize int64) (*os.File, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return f, nil
+	}
+	if fi.Size() >= uncompressedSize || !validGzip(f, sum) {
+		f.Close()
+		return nil, nil
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// validGzip reports whether r is gzip compressed content with SHA-256 hash sum.
+func validGzip(r io.Reader, sum [sha256.Size]byte) bool {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return false
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, gr)
+	if err != nil {
+		return false
+	}
+	var got [sha256.Size]byte
+	h.Sum(got[:0])
+	return got == sum
+}
+
+// writeStoredFileInfo writes CompressedFileInfo, or the compressed content with
+// other templates named name, with the gzip compressed content of a cache entry.
+// It returns errCompressedNotSmaller if the entry is empty.
+func writeStoredFileInfo(w io.Writer, t *template.Template, name string, file *FileInfo, entry io.Reader) error {
+	err := t.ExecuteTemplate(w, name+"-Before", file)
+	if err != nil {
+		return err
+	}
+	sw := &stringWriter{Writer: w}
+	_, err = io.Copy(sw, entry)
+	if err != nil {
+		return err
+	}
+	if sw.N == 0 {
+		return errCompressedNotSmaller
+	}
+	file.StoredSize, file.Compressed = sw.N, true
+	return t.ExecuteTemplate(w, name+"-After", file)
+}
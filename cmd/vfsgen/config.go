@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/shurcooL/vfsgen"
+)
+
+// config is a vfsgen configuration file, which declares bundles to generate.
+type config struct {
+	Bundles []bundle `toml:"bundles" json:"bundles"`
+}
+
+// bundle declares the inputs and options of one generated file.
+// Its fields correspond to the flags of the same name.
+type bundle struct {
+	Mounts        []mount  `toml:"mounts" json:"mounts"`
+	Output        string   `toml:"output" json:"output"`
+	Manifest      string   `toml:"manifest" json:"manifest"`
+	Package       string   `toml:"package" json:"package"`
+	Tags          string   `toml:"tags" json:"tags"`
+	Variable      string   `toml:"variable" json:"variable"`
+	Comment       string   `toml:"comment" json:"comment"`
+	TypePrefix    string   `toml:"typePrefix" json:"typePrefix"`
+	Runtime       bool     `toml:"runtime" json:"runtime"`
+	PathConstants bool     `toml:"pathConstants" json:"pathConstants"`
+	NoCompress    bool     `toml:"noCompress" json:"noCompress"`
+	Level         int      `toml:"level" json:"level"`
+	Cache         string   `toml:"cache" json:"cache"`
+	Minify        []string `toml:"minify" json:"minify"`
+	Templates     string   `toml:"templates" json:"templates"`
+	Strip         string   `toml:"strip" json:"strip"`
+	Prefix        string   `toml:"prefix" json:"prefix"`
+	IgnoreFile    bool     `toml:"ignoreFile" json:"ignoreFile"`
+	ModTime       string   `toml:"modtime" json:"modtime"`
+	Conflict      string   `toml:"conflict" json:"conflict"`
+	Include       []string `toml:"include" json:"include"`
+	Exclude       []string `toml:"exclude" json:"exclude"`
+	Budget        budget   `toml:"budget" json:"budget"`
+}
+
+// budget declares the size limits of a bundle.
+type budget struct {
+	MaxTotalSize int64   `toml:"maxTotalSize" json:"maxTotalSize"`
+	MaxFileSize  int64   `toml:"maxFileSize" json:"maxFileSize"`
+	Limits       []limit `toml:"limits" json:"limits"`
+}
+
+// limit is the maximum total stored size of the files that match Pattern.
+type limit struct {
+	Pattern string `toml:"pattern" json:"pattern"`
+	MaxSize int64  `toml:"maxSize" json:"maxSize"`
+}
+
+// mount is an input directory of a bundle, mounted at path At, or the root if empty.
+type mount struct {
+	At  string `toml:"at" json:"at"`
+	Dir string `toml:"dir" json:"dir"`
+}
+
+// readConfig reads the configuration file filename. Its format is
+// TOML or JSON, depending on the extension of filename.
+// Unknown keys are an error, so that misspelled options aren't ignored.
+func readConfig(filename string) (config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return config{}, err
+	}
+	var c config
+	switch ext := filepath.Ext(filename); ext {
+	case ".toml":
+		md, err := toml.Decode(string(b), &c)
+		if err != nil {
+			return config{}, fmt.Errorf("%s: %v", filename, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return config{}, fmt.Errorf("%s: unknown key %q", filename, undecoded[0].String())
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&c)
+		if err != nil {
+			return config{}, fmt.Errorf("%s: %v", filename, err)
+		}
+	default:
+		return config{}, fmt.Errorf("%s: unknown config file format %q, want .toml or .json", filename, ext)
+	}
+	if len(c.Bundles) == 0 {
+		return config{}, fmt.Errorf("%s: no bundles", filename)
+	}
+	return c, nil
+}
+
+// name returns the name of bundle b with index i, for error messages.
+func (b bundle) name(i int) string {
+	if b.Output != "" {
+		return fmt.Sprintf("bundle %d (%s)", i+1, b.Output)
+	}
+	return fmt.Sprintf("bundle %d", i+1)
+}
+
+// options returns the mounts and vfsgen options of bundle b. Relative paths
+// are resolved against dir, the directory of the configuration file.
+func (b bundle) options(dir string) ([]vfsgen.Mount, vfsgen.Options, error) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	if len(b.Mounts) == 0 {
+		return nil, vfsgen.Options{}, errors.New("no mounts")
+	}
+	var mounts []vfsgen.Mount
+	for _, m := range b.Mounts {
+		if m.Dir == "" {
+			return nil, vfsgen.Options{}, errors.New("mount has no dir")
+		}
+		at := m.At
+		if at == "" {
+			at = "/"
+		}
+		d := resolve(m.Dir)
+		fi, err := os.Stat(d)
+		if err != nil {
+			return nil, vfsgen.Options{}, err
+		}
+		if !fi.IsDir() {
+			return nil, vfsgen.Options{}, fmt.Errorf("%s is not a directory", d)
+		}
+		mounts = append(mounts, vfsgen.Mount{At: at, FS: http.Dir(d)})
+	}
+
+	opt := vfsgen.Options{
+		ManifestFilename: resolve(b.Manifest),
+		PackageName:      b.Package,
+		BuildTags:        b.Tags,
+		VariableName:     b.Variable,
+		VariableComment:  b.Comment,
+		TypePrefix:       b.TypePrefix,
+		RuntimeImport:    b.Runtime,
+		PathConstants:    b.PathConstants,
+		NoCompression:    b.NoCompress,
+		CompressionLevel: b.Level,
+		CacheDir:         resolve(b.Cache),
+		StripPrefix:      b.Strip,
+		MountPrefix:      b.Prefix,
+		Include:          b.Include,
+		Exclude:          b.Exclude,
+		IgnoreFile:       b.IgnoreFile,
+		Budget: vfsgen.Budget{
+			MaxTotalSize: b.Budget.MaxTotalSize,
+			MaxFileSize:  b.Budget.MaxFileSize,
+		},
+	}
+	for _, l := range b.Budget.Limits {
+		opt.Budget.Limits = append(opt.Budget.Limits, vfsgen.BudgetLimit{Pattern: l.Pattern, MaxSize: l.MaxSize})
+	}
+	// Options.Filename defaults relative to the current directory,
+	// so apply its default here to keep it relative to dir.
+	opt.Filename = b.Output
+	if opt.Filename == "" {
+		variableName := opt.VariableName
+		if variableName == "" {
+			variableName = "assets"
+		}
+		opt.Filename = strings.ToLower(variableName) + "_vfsdata.go"
+	}
+	opt.Filename = resolve(opt.Filename)
+
+	var err error
+	opt.Conflict, err = parseConflict(b.Conflict)
+	if err != nil {
+		return nil, vfsgen.Options{}, fmt.Errorf("conflict has invalid value: %v", err)
+	}
+	opt.ModTime, err = parseModTime(b.ModTime)
+	if err != nil {
+		return nil, vfsgen.Options{}, fmt.Errorf("modtime has invalid value: %v", err)
+	}
+	opt.Transform, err = parseMinify(b.Minify)
+	if err != nil {
+		return nil, vfsgen.Options{}, fmt.Errorf("minify has invalid value: %v", err)
+	}
+	if b.Templates != "" {
+		t, err := os.ReadFile(resolve(b.Templates))
+		if err != nil {
+			return nil, vfsgen.Options{}, err
+		}
+		opt.Templates = string(t)
+	}
+	return mounts, opt, nil
+}
+
+// runConfig generates all bundles in configuration file filename in one run.
+// All bundles are checked before any is generated, and then they're generated
+// concurrently. Errors for all bundles are reported together.
+func runConfig(filename string) error {
+	c, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(filename)
+
+	type job struct {
+		name   string
+		mounts []vfsgen.Mount
+		opt    vfsgen.Options
+	}
+	var (
+		jobs    []job
+		errs    []string
+		outputs = make(map[string]string) // Output filename -> bundle name.
+	)
+	for i, b := range c.Bundles {
+		name := b.name(i)
+		mounts, opt, err := b.options(dir)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s: %v", filename, name, err))
+			continue
+		}
+		if other, ok := outputs[opt.Filename]; ok {
+			errs = append(errs, fmt.Sprintf("%s: %s: output file %s is also written by %s", filename, name, opt.Filename, other))
+			continue
+		}
+		outputs[opt.Filename] = name
+		jobs = append(jobs, job{name, mounts, opt})
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	errs = make([]string, len(jobs))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func(i int, j job) {
+			defer wg.Done()
+			err := vfsgen.GenerateMounts(j.mounts, j.opt)
+			if err != nil {
+				errs[i] = fmt.Sprintf("%s: %s: %v", filename, j.name, err)
+			}
+		}(i, j)
+	}
+	wg.Wait()
+	var failed []string
+	for _, e := range errs {
+		if e != "" {
+			failed = append(failed, e)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	want := config{Bundles: []bundle{{
+		Mounts:   []mount{{Dir: "static"}, {At: "/docs", Dir: "docs"}},
+		Output:   "assets_vfsdata.go",
+		Package:  "web",
+		Variable: "assets",
+		Exclude:  []string{"*.map"},
+		Budget:   budget{MaxFileSize: 1000, Limits: []limit{{Pattern: "*.mp4", MaxSize: 0}}},
+	}}}
+	tests := []struct {
+		name    string
+		content string
+		want    config
+		wantErr string // Substring of the error, or empty if none is wanted.
+	}{
+		{
+			name: "vfsgen.toml",
+			content: `[[bundles]]
+package = "web"
+variable = "assets"
+output = "assets_vfsdata.go"
+mounts = [{ dir = "static" }, { at = "/docs", dir = "docs" }]
+exclude = ["*.map"]
+budget = { maxFileSize = 1000, limits = [{ pattern = "*.mp4", maxSize = 0 }] }
+`,
+			want: want,
+		},
+		{
+			name: "vfsgen.json",
+			content: `{"bundles": [{
+	"package": "web",
+	"variable": "assets",
+	"output": "assets_vfsdata.go",
+	"mounts": [{"dir": "static"}, {"at": "/docs", "dir": "docs"}],
+	"exclude": ["*.map"],
+	"budget": {"maxFileSize": 1000, "limits": [{"pattern": "*.mp4", "maxSize": 0}]}
+}]}`,
+			want: want,
+		},
+		{
+			name:    "vfsgen.toml",
+			content: "[[bundles]]\nmounts = [{ dir = \"static\" }]\nnoCompression = true\n",
+			wantErr: `unknown key "bundles.noCompression"`,
+		},
+		{
+			name:    "vfsgen.json",
+			content: `{"bundles": [{"mounts": [{"dir": "static"}], "noCompression": true}]}`,
+			wantErr: `unknown field "noCompression"`,
+		},
+		{
+			name:    "vfsgen.toml",
+			content: "",
+			wantErr: "no bundles",
+		},
+		{
+			name:    "vfsgen.yaml",
+			content: "bundles: []",
+			wantErr: "unknown config file format",
+		},
+	}
+	for _, tc := range tests {
+		filename := filepath.Join(t.TempDir(), tc.name)
+		err := os.WriteFile(filename, []byte(tc.content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := readConfig(filename)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s %q: got error %v, want one containing %q", tc.name, tc.content, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBundleOptions(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"static", "docs"} {
+		err := os.Mkdir(filepath.Join(dir, d), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	abs := filepath.Join(t.TempDir(), "cache")
+
+	// Relative paths are resolved against the directory of the configuration file,
+	// and absolute ones are kept.
+	mounts, opt, err := bundle{
+		Mounts:   []mount{{Dir: "static"}, {At: "/docs", Dir: "docs"}},
+		Output:   "out/assets_vfsdata.go",
+		Manifest: "manifest.json",
+		Cache:    abs,
+	}.options(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(mounts), 2; got != want {
+		t.Fatalf("got %d mounts, want %d", got, want)
+	}
+	for i, want := range []string{"/", "/docs"} {
+		if got := mounts[i].At; got != want {
+			t.Errorf("mount %d: got At %q, want %q", i, got, want)
+		}
+	}
+	for _, tc := range []struct{ name, got, want string }{
+		{"Filename", opt.Filename, filepath.Join(dir, "out", "assets_vfsdata.go")},
+		{"ManifestFilename", opt.ManifestFilename, filepath.Join(dir, "manifest.json")},
+		{"CacheDir", opt.CacheDir, abs},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("got %s %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+
+	// The default output name is derived from the variable name.
+	for _, tc := range []struct{ variable, want string }{
+		{"", "assets_vfsdata.go"},
+		{"Templates", "templates_vfsdata.go"},
+	} {
+		_, opt, err := bundle{Mounts: []mount{{Dir: "static"}}, Variable: tc.variable}.options(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := opt.Filename, filepath.Join(dir, tc.want); got != want {
+			t.Errorf("variable %q: got Filename %q, want %q", tc.variable, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		b       bundle
+		wantErr string
+	}{
+		{bundle{}, "no mounts"},
+		{bundle{Mounts: []mount{{At: "/docs"}}}, "mount has no dir"},
+		{bundle{Mounts: []mount{{Dir: "static"}}, Conflict: "merge"}, "conflict has invalid value"},
+	} {
+		_, _, err := tc.b.options(dir)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("bundle %+v: got error %v, want one containing %q", tc.b, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRunConfig_duplicateOutputs(t *testing.T) {
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "static"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "vfsgen.toml")
+	err = os.WriteFile(filename, []byte(`[[bundles]]
+variable = "assets"
+mounts = [{ dir = "static" }]
+
+[[bundles]]
+output = "assets_vfsdata.go"
+mounts = [{ dir = "static" }]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = runConfig(filename)
+	if err == nil || !strings.Contains(err.Error(), "is also written by bundle 1") {
+		t.Errorf("got error %v, want one about a duplicate output", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "assets_vfsdata.go")); !os.IsNotExist(err) {
+		t.Errorf("output file was written despite duplicate outputs: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+// vfsgen generates Go code that statically implements the contents of directories on disk.
+//
+// It's a standalone alternative to writing a program that calls vfsgen.Generate,
+// meant to be used directly in go generate directives, such as:
+//
+//	//go:generate vfsgen -pkg web -var assets -o assets_vfsdata.go -tags !dev ./static
+//
+// Each directory argument is mounted at the root of the generated filesystem.
+// A directory can be mounted at another path with the -mount flag, such as
+// -mount /docs=./docs, which can be repeated. Directory arguments are mounted
+// first, followed by the -mount flags, in order.
+//
+// With the -config flag, vfsgen instead generates all bundles declared in a TOML or
+// JSON configuration file in one run, such as:
+//
+//	//go:generate vfsgen -config vfsgen.toml
+//
+// Each bundle in the file has the inputs and options of one generated file, with keys
+// named after the flags, and relative paths resolved against the directory of the file:
+//
+//	[[bundles]]
+//	package = "web"
+//	variable = "assets"
+//	output = "assets_vfsdata.go"
+//	tags = "!dev"
+//	mounts = [{ dir = "static" }, { at = "/docs", dir = "docs" }]
+//	exclude = ["*.map"]
+//	level = 6
+//
+// A JSON configuration file has the same structure, as {"bundles": [...]}.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/shurcooL/vfsgen"
+	"github.com/shurcooL/vfsgen/internal/optparse"
+)
+
+var (
+	configFlag        = flag.String("config", "", "TOML or JSON file that declares bundles to generate, instead of flags and directory arguments.")
+	outputFlag        = flag.String("o", "", `Filename of the generated Go code output. (default "{{toLower var}}_vfsdata.go")`)
+	manifestFlag      = flag.String("manifest", "", "Filename of an optional JSON manifest to write alongside the generated Go code.")
+	pkgFlag           = flag.String("pkg", "main", "Name of the package in the generated code.")
+	tagsFlag          = flag.String("tags", "", "Build tags in the generated code.")
+	varFlag           = flag.String("var", "assets", "Name of the http.FileSystem variable in the generated code.")
+	commentFlag       = flag.String("comment", "", "Comment of the variable in the generated code.")
+	typePrefixFlag    = flag.String("type-prefix", "", `Prefix of the names of the types declared in the generated code. (default "vfsgen۰")`)
+	runtimeFlag       = flag.Bool("runtime", false, "Import the vfsgenrt package for the implementation of the filesystem, instead of declaring types.")
+	pathConstantsFlag = flag.Bool("path-constants", false, "Generate a path type with a constant for each file and directory.")
+	noCompressFlag    = flag.Bool("no-compress", false, "Store all files uncompressed.")
+	levelFlag         = flag.Int("level", 0, "Gzip compression level, from 1 (best speed) to 9 (best compression). (default 9)")
+	cacheFlag         = flag.String("cache", "", "Directory in which to cache compressed file contents across runs.")
+	minifyFlag        = flag.String("minify", "", `Comma-separated list of file types to minify: "json", "css", "html", "svg" or "all".`)
+	templatesFlag     = flag.String("templates", "", "File with template definitions that override the templates used to produce the generated code.")
+	stripFlag         = flag.String("strip", "", "Path of a directory in the input; only its contents are included, with the path removed.")
+	prefixFlag        = flag.String("prefix", "", "Path added to the start of all paths in the generated filesystem.")
+	ignoreFileFlag    = flag.Bool("ignore-file", false, "Read a .vfsgenignore file with gitignore syntax from the root of each directory.")
+	modTimeFlag       = flag.String("modtime", "keep", `Modification times in the generated code: "keep" or "zero".`)
+	conflictFlag      = flag.String("conflict", "error", `How to resolve paths provided by more than one directory: "error", "first" or "last".`)
+	maxTotalSizeFlag  = flag.Int64("max-total-size", 0, "Maximum total stored size of all files, in bytes. (default no limit)")
+	maxFileSizeFlag   = flag.Int64("max-file-size", 0, "Maximum stored size of each file, in bytes. (default no limit)")
+	mountFlag         stringsFlag
+	includeFlag       stringsFlag
+	excludeFlag       stringsFlag
+	limitFlag         stringsFlag
+)
+
+func init() {
+	flag.Var(&mountFlag, "mount", `Directory to mount at a path in the generated filesystem, as "/at=dir"; can be repeated.`)
+	flag.Var(&includeFlag, "include", "Pattern of files to include; can be repeated. (default all files)")
+	flag.Var(&excludeFlag, "exclude", "Pattern of files and directories to exclude; can be repeated.")
+	flag.Var(&limitFlag, "limit", `Maximum total stored size of the files that match a pattern, as "pattern=bytes"; can be repeated.`)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: vfsgen [flags] dir...`)
+	fmt.Fprintln(os.Stderr, `       vfsgen [flags] -mount /at=dir... [dir...]`)
+	fmt.Fprintln(os.Stderr, `       vfsgen -config file`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *configFlag != "" {
+		err := checkConfigFlags()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr)
+			flag.Usage()
+			os.Exit(2)
+		}
+		err = runConfig(*configFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() == 0 && len(mountFlag) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	mounts, err := parseMounts(flag.Args(), mountFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr)
+		flag.Usage()
+		os.Exit(2)
+	}
+	opt, err := options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = vfsgen.GenerateMounts(mounts, opt)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// checkConfigFlags returns an error if directory arguments or flags other than
+// -config are specified, since a configuration file specifies all of them.
+func checkConfigFlags() error {
+	if flag.NArg() != 0 {
+		return errors.New("-config flag is not valid with directory arguments")
+	}
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "config" && err == nil {
+			err = fmt.Errorf("-%s flag is not valid with -config flag", f.Name)
+		}
+	})
+	return err
+}
+
+// parseMounts returns the mounts of directory arguments dirs, which are mounted
+// at the root, followed by those of -mount flag values of the form "/at=dir".
+// The path ends at the first "=", so that the directory can contain "=".
+func parseMounts(dirs, mountFlags []string) ([]vfsgen.Mount, error) {
+	var mounts []vfsgen.Mount
+	add := func(at, dir string) error {
+		fi, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		mounts = append(mounts, vfsgen.Mount{At: at, FS: http.Dir(dir)})
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := add("/", dir); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range mountFlags {
+		at, dir, ok := strings.Cut(m, "=")
+		if !ok || !strings.HasPrefix(at, "/") || dir == "" {
+			return nil, fmt.Errorf("-mount flag has invalid value: %q is not of the form /at=dir", m)
+		}
+		if err := add(at, dir); err != nil {
+			return nil, err
+		}
+	}
+	return mounts, nil
+}
+
+// options returns the vfsgen options specified by flags.
+func options() (vfsgen.Options, error) {
+	opt := vfsgen.Options{
+		Filename:         *outputFlag,
+		ManifestFilename: *manifestFlag,
+		PackageName:      *pkgFlag,
+		BuildTags:        *tagsFlag,
+		VariableName:     *varFlag,
+		VariableComment:  *commentFlag,
+		TypePrefix:       *typePrefixFlag,
+		RuntimeImport:    *runtimeFlag,
+		PathConstants:    *pathConstantsFlag,
+		NoCompression:    *noCompressFlag,
+		CompressionLevel: *levelFlag,
+		CacheDir:         *cacheFlag,
+		StripPrefix:      *stripFlag,
+		MountPrefix:      *prefixFlag,
+		Include:          includeFlag,
+		Exclude:          excludeFlag,
+		IgnoreFile:       *ignoreFileFlag,
+		Budget: vfsgen.Budget{
+			MaxTotalSize: *maxTotalSizeFlag,
+			MaxFileSize:  *maxFileSizeFlag,
+		},
+	}
+
+	for _, l := range limitFlag {
+		limit, err := optparse.ParseLimit(l)
+		if err != nil {
+			return vfsgen.Options{}, fmt.Errorf("-limit flag has invalid value: %v", err)
+		}
+		opt.Budget.Limits = append(opt.Budget.Limits, limit)
+	}
+
+	var err error
+	opt.Conflict, err = parseConflict(*conflictFlag)
+	if err != nil {
+		return vfsgen.Options{}, fmt.Errorf("-conflict flag has invalid value: %v", err)
+	}
+	opt.ModTime, err = parseModTime(*modTimeFlag)
+	if err != nil {
+		return vfsgen.Options{}, fmt.Errorf("-modtime flag has invalid value: %v", err)
+	}
+	if *minifyFlag != "" {
+		opt.Transform, err = parseMinify(strings.Split(*minifyFlag, ","))
+		if err != nil {
+			return vfsgen.Options{}, fmt.Errorf("-minify flag has invalid value: %v", err)
+		}
+	}
+
+	if *templatesFlag != "" {
+		b, err := os.ReadFile(*templatesFlag)
+		if err != nil {
+			return vfsgen.Options{}, err
+		}
+		opt.Templates = string(b)
+	}
+
+	return opt, nil
+}
+
+// parseConflict parses the name of a conflict policy.
+func parseConflict(s string) (vfsgen.Conflict, error) {
+	switch s {
+	case "", "error":
+		return vfsgen.ConflictError, nil
+	case "first":
+		return vfsgen.ConflictFirstWins, nil
+	case "last":
+		return vfsgen.ConflictLastWins, nil
+	default:
+		return 0, fmt.Errorf("unknown conflict policy %q", s)
+	}
+}
+
+// parseModTime parses the name of a modification time policy.
+func parseModTime(s string) (vfsgen.ModTimePolicy, error) {
+	m, err := optparse.ParseModTime(s)
+	return m.Policy, err
+}
+
+// parseMinify returns the transforms that minify the given file types.
+func parseMinify(types []string) ([]vfsgen.Transform, error) {
+	ms, err := optparse.ParseMinify(types)
+	if err != nil {
+		return nil, err
+	}
+	var transforms []vfsgen.Transform
+	for _, m := range ms {
+		transforms = append(transforms, m.Transform)
+	}
+	return transforms, nil
+}
+
+// stringsFlag is a flag that can be repeated to specify multiple values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
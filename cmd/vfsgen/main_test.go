@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+func TestParseMounts(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"static", "docs", "a=b"} {
+		err := os.Mkdir(filepath.Join(dir, d), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	static, docs, ab := filepath.Join(dir, "static"), filepath.Join(dir, "docs"), filepath.Join(dir, "a=b")
+
+	// Directory arguments are mounted at the root, even when they're absolute
+	// paths that contain "=", followed by -mount flags.
+	mounts, err := parseMounts([]string{static, ab}, []string{"/docs=" + docs, "/x=" + ab})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vfsgen.Mount{
+		{At: "/", FS: http.Dir(static)},
+		{At: "/", FS: http.Dir(ab)},
+		{At: "/docs", FS: http.Dir(docs)},
+		{At: "/x", FS: http.Dir(ab)},
+	}
+	if !reflect.DeepEqual(mounts, want) {
+		t.Errorf("got mounts %v, want %v", mounts, want)
+	}
+
+	for _, tc := range []struct {
+		dirs, mounts []string
+		wantErr      string
+	}{
+		{dirs: []string{filepath.Join(dir, "missing")}, wantErr: "no such file or directory"},
+		{mounts: []string{"docs"}, wantErr: `-mount flag has invalid value: "docs" is not of the form /at=dir`},
+		{mounts: []string{"docs=" + docs}, wantErr: "is not of the form /at=dir"},
+		{mounts: []string{"/docs="}, wantErr: "is not of the form /at=dir"},
+		{mounts: []string{"/docs=" + filepath.Join(static, "missing")}, wantErr: "no such file or directory"},
+	} {
+		_, err := parseMounts(tc.dirs, tc.mounts)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("dirs %q, mounts %q: got error %v, want one containing %q", tc.dirs, tc.mounts, err, tc.wantErr)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	templates := filepath.Join(t.TempDir(), "templates.tmpl")
+	err := os.WriteFile(templates, []byte(`{{define "Header"}}{{end}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setFlags(t,
+		"-o=out.go", "-manifest=manifest.json", "-pkg=web", "-tags=!dev", "-var=Assets",
+		"-comment=Assets are the assets.", "-type-prefix=assets", "-runtime", "-path-constants",
+		"-no-compress", "-level=1", "-cache=cache", "-minify=json,css", "-templates="+templates,
+		"-strip=/dist", "-prefix=/static", "-ignore-file", "-modtime=zero", "-conflict=last",
+		"-max-total-size=1000", "-max-file-size=100", "-include=*.html", "-include=*.css",
+		"-exclude=*.map", "-limit=*.png=10",
+	)
+	opt, err := options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Transforms are functions, which can't be compared.
+	if got, want := len(opt.Transform), 2; got != want {
+		t.Errorf("got %d transforms, want %d", got, want)
+	}
+	opt.Transform = nil
+	want := vfsgen.Options{
+		Filename:         "out.go",
+		ManifestFilename: "manifest.json",
+		PackageName:      "web",
+		BuildTags:        "!dev",
+		VariableName:     "Assets",
+		VariableComment:  "Assets are the assets.",
+		TypePrefix:       "assets",
+		RuntimeImport:    true,
+		PathConstants:    true,
+		NoCompression:    true,
+		CompressionLevel: 1,
+		CacheDir:         "cache",
+		Templates:        `{{define "Header"}}{{end}}`,
+		StripPrefix:      "/dist",
+		MountPrefix:      "/static",
+		Include:          []string{"*.html", "*.css"},
+		Exclude:          []string{"*.map"},
+		IgnoreFile:       true,
+		ModTime:          vfsgen.ModTimeZero,
+		Conflict:         vfsgen.ConflictLastWins,
+		Budget: vfsgen.Budget{
+			MaxTotalSize: 1000,
+			MaxFileSize:  100,
+			Limits:       []vfsgen.BudgetLimit{{Pattern: "*.png", MaxSize: 10}},
+		},
+	}
+	if !reflect.DeepEqual(opt, want) {
+		t.Errorf("got options:\n%+v\nwant:\n%+v", opt, want)
+	}
+
+	for _, tc := range []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-limit=*.png"}, "-limit flag has invalid value"},
+		{[]string{"-conflict=merge"}, "-conflict flag has invalid value"},
+		{[]string{"-modtime=now"}, "-modtime flag has invalid value"},
+		{[]string{"-minify=xml"}, "-minify flag has invalid value"},
+	} {
+		setFlags(t, tc.args...)
+		_, err := options()
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%q: got error %v, want one containing %q", tc.args, err, tc.wantErr)
+		}
+	}
+}
+
+// setFlags resets the flags of vfsgen to their defaults, and parses args.
+// Flags are reset again when the test finishes.
+func setFlags(t *testing.T, args ...string) {
+	t.Helper()
+	resetFlags()
+	t.Cleanup(resetFlags)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resetFlags() {
+	flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "test.") {
+			return
+		}
+		if v, ok := f.Value.(*stringsFlag); ok {
+			*v = nil
+			return
+		}
+		f.Value.Set(f.DefValue)
+	})
+}
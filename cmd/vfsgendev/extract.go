@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// extractMain implements the "extract" subcommand.
+func extractMain(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	sourceFlag := fs.String("source", "", "Specifies the http.FileSystem variable to extract.")
+	tagsFlag := fs.String("tags", "", "Build tags to use for source. By default, the generated (non-dev) variable is extracted.")
+	gzFlag := fs.Bool("gz", false, "Keep gzip compressed files as is, with a .gz extension added.")
+	nFlag := fs.Bool("n", false, "Print the generated source but do not run it.")
+	fs.StringVar(modFlag, "mod", "", "Module download mode (readonly, vendor or mod) passed to the go command.")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, `Usage: vfsgendev extract [flags] -source="import/path".VariableName dir`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	importPath, variableName, err := parseSourceFlag(*sourceFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "-source flag has invalid value:", err)
+		fmt.Fprintln(os.Stderr)
+		fs.Usage()
+		os.Exit(2)
+	}
+	dir, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	tags := strings.FieldsFunc(*tagsFlag, func(r rune) bool { return r == ',' || r == ' ' })
+	pkg, err := loadPackage(importPath, tags)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = extractTemplate.Execute(&buf, extractData{
+		ImportPath:   pkg.PkgPath,
+		VariableName: variableName,
+		Dir:          dir,
+		KeepGzip:     *gzFlag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *nFlag {
+		io.Copy(os.Stdout, &buf)
+		return nil
+	}
+
+	return goRun(buf.String(), tags, moduleDir(pkg), packageDir(pkg))
+}
+
+type extractData struct {
+	ImportPath   string
+	VariableName string
+	Dir          string
+	KeepGzip     bool
+}
+
+var extractTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`package main
+
+import (
+	"log"
+
+	"github.com/shurcooL/vfsgen"
+
+	sourcepkg {{.ImportPath | quote}}
+)
+
+func main() {
+	err := vfsgen.Extract(sourcepkg.{{.VariableName}}, {{.Dir | quote}}, vfsgen.ExtractOptions{
+		KeepGzip: {{.KeepGzip}},
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+`))
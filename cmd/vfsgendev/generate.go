@@ -3,14 +3,39 @@ package main
 import (
 	"strconv"
 	"text/template"
+	"time"
 )
 
 type data struct {
-	ImportPath      string
-	PackageName     string
-	BuildTags       string
-	VariableName    string
-	VariableComment string
+	Imports  []sourceImport
+	Sources  []source
+	Options  options       // Options that apply to all sources.
+	Dirs     []string      // Distinct directories of source packages, in order.
+	Watch    bool          // Watch sources and generate code when they change, until interrupted.
+	Interval time.Duration // Interval between polls when watching.
+}
+
+// sourceImport is an imported source package.
+type sourceImport struct {
+	Name       string // Name of the import in the generated program.
+	ImportPath string
+}
+
+// source is a variable to generate code for.
+type source struct {
+	Source           string // Source as specified by the -source flag.
+	Import           string // Name of the import that provides the source.
+	SourceName       string // Name of the variable or function in the source package.
+	Kind             sourceKind
+	Dir              string // Directory of the source package.
+	PackageName      string
+	BuildTags        string
+	VariableName     string // Name of the variable in the generated code.
+	VariableComment  string
+	TypePrefix       string
+	Templates        string // Options.Templates, with the declaration of the function for a function source.
+	Filename         string
+	ManifestFilename string
 }
 
 var generateTemplate = template.Must(template.New("").Funcs(template.FuncMap{
@@ -18,22 +43,180 @@ var generateTemplate = template.Must(template.New("").Funcs(template.FuncMap{
 }).Parse(`package main
 
 import (
-	"log"
+{{- if .Watch}}
+	"context"{{end}}
+	"fmt"
+	"net/http"
+	"os"
+{{- if not .Watch}}
+	pathpkg "path"{{end}}
+{{- if .Watch}}
+	"os/signal"{{end}}
+	"sync"
+{{- if .Watch}}
+	"time"{{end}}
 
 	"github.com/shurcooL/vfsgen"
-
-	sourcepkg {{.ImportPath | quote}}
+{{range .Imports}}
+	{{.Name}} {{.ImportPath | quote}}{{end}}
 )
 
 func main() {
-	err := vfsgen.Generate(sourcepkg.{{.VariableName}}, vfsgen.Options{
-		PackageName:     {{.PackageName | quote}},
-		BuildTags:       {{.BuildTags | quote}},
-		VariableName:    {{.VariableName | quote}},
-		VariableComment: {{.VariableComment | quote}},
-	})
+	sources := []struct {
+		name string
+		dir  string
+		fs   func() (http.FileSystem, error)
+		opt  vfsgen.Options
+	}{
+{{- range .Sources}}
+		{
+			name: {{.Source | quote}},
+			dir:  {{.Dir | quote}},
+			fs: func() (http.FileSystem, error) {
+{{- if .Kind.ReturnsError}}
+				return {{.Import}}.{{.SourceName}}()
+{{- else}}
+				return {{.Import}}.{{.SourceName}}{{if .Kind.Call}}(){{end}}, nil
+{{- end}}
+			},
+			opt: vfsgen.Options{
+				Filename: {{.Filename | quote}},
+{{- with .ManifestFilename}}
+				ManifestFilename: {{quote .}},{{end}}
+				PackageName: {{.PackageName | quote}},
+				BuildTags: {{.BuildTags | quote}},
+				VariableName: {{.VariableName | quote}},
+				VariableComment: {{.VariableComment | quote}},
+{{- with .TypePrefix}}
+				TypePrefix: {{quote .}},{{end}}
+{{- with .Templates}}
+				Templates: {{quote .}},{{end}}
+{{- with $.Options}}
+{{- if .RuntimeImport}}
+				RuntimeImport: true,{{end}}
+{{- if .PathConstants}}
+				PathConstants: true,{{end}}
+{{- if .NoCompression}}
+				NoCompression: true,{{end}}
+{{- with .CompressionLevel}}
+				CompressionLevel: {{.}},{{end}}
+{{- with .CacheDir}}
+				CacheDir: {{quote .}},{{end}}
+{{- with .Transform}}
+				Transform: []vfsgen.Transform{ {{- range $i, $t := .}}{{if $i}}, {{end}}vfsgen.{{$t}}{{end -}} },{{end}}
+{{- with .StripPrefix}}
+				StripPrefix: {{quote .}},{{end}}
+{{- with .MountPrefix}}
+				MountPrefix: {{quote .}},{{end}}
+{{- with .Include}}
+				Include: {{printf "%#v" .}},{{end}}
+{{- with .Exclude}}
+				Exclude: {{printf "%#v" .}},{{end}}
+{{- if .IgnoreFile}}
+				IgnoreFile: true,{{end}}
+{{- with .ModTime}}
+				ModTime: vfsgen.{{.}},{{end}}
+{{- if or .MaxTotalSize .MaxFileSize .Limits}}
+				Budget: vfsgen.Budget{
+{{- with .MaxTotalSize}}
+					MaxTotalSize: {{.}},{{end}}
+{{- with .MaxFileSize}}
+					MaxFileSize: {{.}},{{end}}
+{{- with .Limits}}
+					Limits: []vfsgen.BudgetLimit{
+{{- range .}}
+						{Pattern: {{quote .Pattern}}, MaxSize: {{.MaxSize}}},{{end}}
+					},{{end}}
+				},{{end}}
+{{- end}}
+			},
+		},
+{{- end}}
+	}
+{{if .Watch}}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+{{end}}
+	// Generate sources with the working directory set to the directory of their
+	// package, so that relative paths in them resolve the same way as in the package.
+	// Sources in the same directory are generated in parallel, and all errors are
+	// reported at the end.
+	errs := make([]error, len(sources))
+	for _, dir := range []string{ {{- range $i, $d := .Dirs}}{{if $i}}, {{end}}{{quote $d}}{{end -}} } {
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var wg sync.WaitGroup
+		for i := range sources {
+			if sources[i].dir != dir {
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				fs, err := sources[i].fs()
+				if err != nil {
+					errs[i] = err
+					return
+				}
+{{- if .Watch}}
+				// Watch warns about generated filesystems without files itself.
+				errs[i] = vfsgen.Watch(ctx, fs, sources[i].opt, time.Duration({{printf "%d" .Interval}}))
+{{- else}}
+				errs[i] = vfsgen.Generate(fs, sources[i].opt)
+				if errs[i] != nil {
+					return
+				}
+				// Check the generated filesystem rather than the source, so that files
+				// left out by the options are taken into account.
+				if generated, err := vfsgen.LoadGenerated(sources[i].opt.Filename); err == nil {
+					if empty, err := isEmpty(generated, "/"); err == nil && empty {
+						fmt.Fprintf(os.Stderr, "warning: %s: generated filesystem has no files\n", sources[i].name)
+					}
+				}
+{{- end}}
+			}(i)
+		}
+		wg.Wait()
+	}
+	failed := false
+	for i, err := range errs {
+		if err != nil{{if .Watch}} && err != context.Canceled{{end}} {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sources[i].name, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+{{- if not .Watch}}
+
+// isEmpty reports whether the directory at path in fs has no files, at any depth.
+// It uses only http.FileSystem, so that this program imports nothing but vfsgen
+// and the source packages, and builds with the requirements of their module.
+func isEmpty(fs http.FileSystem, path string) (bool, error) {
+	f, err := fs.Open(path)
 	if err != nil {
-		log.Fatalln(err)
+		return false, err
+	}
+	defer f.Close()
+	fis, err := f.Readdir(0)
+	if err != nil {
+		return false, err
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			return false, nil
+		}
+		empty, err := isEmpty(fs, pathpkg.Join(path, fi.Name()))
+		if err != nil || !empty {
+			return false, err
+		}
 	}
+	return true, nil
 }
+{{- end}}
 `))
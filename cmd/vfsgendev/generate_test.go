@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+// Verify that the generator program is valid Go code for combinations of options,
+// and that it sets them.
+func TestGenerateTemplate(t *testing.T) {
+	assets := source{
+		Source:          `"example.com/assets".Assets`,
+		Import:          "assets",
+		SourceName:      "Assets",
+		Kind:            sourceKind{Type: "http.Dir"},
+		Dir:             "/src/assets",
+		PackageName:     "assets",
+		BuildTags:       "!dev",
+		VariableName:    "Assets",
+		VariableComment: "Assets statically implements the virtual filesystem provided to vfsgen.",
+		Filename:        "/src/assets/assets_vfsdata.go",
+	}
+	docs := source{
+		Source:           `"example.com/assets".NewDocs`,
+		Import:           "assets",
+		SourceName:       "NewDocs",
+		Kind:             sourceKind{Call: true, ReturnsError: true},
+		Dir:              "/src/assets",
+		PackageName:      "assets",
+		BuildTags:        "!dev",
+		VariableName:     "docs",
+		TypePrefix:       "docs",
+		Templates:        `{{define "Footer"}}{{end}}`,
+		Filename:         "/src/assets/docs_vfsdata.go",
+		ManifestFilename: "/src/assets/docs_manifest.json",
+	}
+	imports := []sourceImport{{Name: "assets", ImportPath: "example.com/assets"}}
+	tests := []struct {
+		name    string
+		d       data
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "defaults",
+			d:    data{Imports: imports, Sources: []source{assets}, Dirs: []string{"/src/assets"}},
+			want: []string{
+				`return assets.Assets, nil`,
+				`Filename: "/src/assets/assets_vfsdata.go",`,
+				`BuildTags: "!dev",`,
+				`errs[i] = vfsgen.Generate(fs, sources[i].opt)`,
+				`vfsgen.LoadGenerated(sources[i].opt.Filename)`,
+			},
+			notWant: []string{"ManifestFilename", "TypePrefix", "Templates", "Budget", "Transform", "ModTime", "Include", "Exclude", "signal", "vfsgen.Watch"},
+		},
+		{
+			name: "function source",
+			d:    data{Imports: imports, Sources: []source{assets, docs}, Dirs: []string{"/src/assets"}},
+			want: []string{
+				`return assets.NewDocs()`,
+				`ManifestFilename: "/src/assets/docs_manifest.json",`,
+				`TypePrefix: "docs",`,
+				`Templates: "{{define \"Footer\"}}{{end}}",`,
+			},
+		},
+		{
+			name: "options",
+			d: data{Imports: imports, Sources: []source{assets}, Dirs: []string{"/src/assets"}, Options: options{
+				RuntimeImport:    true,
+				PathConstants:    true,
+				NoCompression:    true,
+				CompressionLevel: 1,
+				CacheDir:         "/cache",
+				Transform:        []string{"MinifyJSON", "MinifyCSS"},
+				StripPrefix:      "/public",
+				MountPrefix:      "/static",
+				Include:          []string{"*.html", "*.css"},
+				Exclude:          []string{"*.map"},
+				IgnoreFile:       true,
+				ModTime:          "ModTimeZero",
+			}},
+			want: []string{
+				`RuntimeImport: true,`,
+				`PathConstants: true,`,
+				`NoCompression: true,`,
+				`CompressionLevel: 1,`,
+				`CacheDir: "/cache",`,
+				`Transform: []vfsgen.Transform{vfsgen.MinifyJSON, vfsgen.MinifyCSS},`,
+				`StripPrefix: "/public",`,
+				`MountPrefix: "/static",`,
+				`Include: []string{"*.html", "*.css"},`,
+				`Exclude: []string{"*.map"},`,
+				`IgnoreFile: true,`,
+				`ModTime: vfsgen.ModTimeZero,`,
+			},
+			notWant: []string{"Budget"},
+		},
+		{
+			name: "budget",
+			d: data{Imports: imports, Sources: []source{assets}, Dirs: []string{"/src/assets"}, Options: options{
+				MaxFileSize: 1 << 20,
+				Limits:      []vfsgen.BudgetLimit{{Pattern: "*.png", MaxSize: 1000}, {Pattern: "/js", MaxSize: 2000}},
+			}},
+			want: []string{
+				`Budget: vfsgen.Budget{ MaxFileSize: 1048576, Limits: []vfsgen.BudgetLimit{ {Pattern: "*.png", MaxSize: 1000}, {Pattern: "/js", MaxSize: 2000}, }, },`,
+			},
+			notWant: []string{"MaxTotalSize"},
+		},
+		{
+			name: "watch",
+			d:    data{Imports: imports, Sources: []source{assets}, Dirs: []string{"/src/assets", "/src/other"}, Watch: true, Interval: 500 * time.Millisecond},
+			want: []string{
+				`ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)`,
+				`errs[i] = vfsgen.Watch(ctx, fs, sources[i].opt, time.Duration(500000000))`,
+				`err != nil && err != context.Canceled`,
+				`[]string{"/src/assets", "/src/other"}`,
+			},
+			notWant: []string{"vfsgen.Generate(", "isEmpty", "pathpkg"},
+		},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := generateTemplate.Execute(&buf, tc.d); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			t.Errorf("%s: generated program isn't valid Go code: %v\n%s", tc.name, err, buf.Bytes())
+			continue
+		}
+		// Compare with whitespace collapsed, since gofmt aligns the options.
+		got := strings.Join(strings.Fields(string(src)), " ")
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: generated program doesn't contain %q:\n%s", tc.name, want, src)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(got, notWant) {
+				t.Errorf("%s: generated program contains %q:\n%s", tc.name, notWant, src)
+			}
+		}
+	}
+}
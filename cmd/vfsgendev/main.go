@@ -1,44 +1,104 @@
 // vfsgendev is a convenience tool for using vfsgen in a common development configuration.
+//
+// The source package is loaded, and the generator program is built and run, in the
+// module context of the current directory, the same way as by go build. So replace
+// directives, go.work files, vendoring, GOFLAGS and the -mod flag are honored.
+// The module that provides the source package needs to require vfsgen.
+// The generator program runs with its working directory set to the directory
+// of the source package, so relative paths in the source, such as in
+// http.Dir("assets"), resolve the same way as in the package. With multiple
+// sources, those in the same package directory are generated in parallel, and
+// package directories one after another.
+//
+// A source can be a variable that implements http.FileSystem, or a function without
+// parameters that returns an http.FileSystem, optionally followed by an error.
+// For a variable, the generated code declares an http.FileSystem variable of the same
+// name. For a function, it declares a function of the same name and signature, which
+// returns an unexported variable named after the function, without a "New" prefix
+// if it has one (NewAssets returns assets), or otherwise with an "FS" suffix.
+// An fs.FS, such as an embed.FS, can't be a source, since the generated code couldn't
+// be used in its place; adapt it with http.FS in the source package instead.
+//
+// With the -watch flag, vfsgendev keeps running after generating code, and generates
+// it again whenever the source filesystems change, until it's interrupted.
+//
+// The "vfsgendev extract" subcommand writes out the contents of a generated
+// http.FileSystem variable to a directory on disk.
 package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"go/build"
+	"go/build/constraint"
+	"go/format"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
 )
 
 var (
-	sourceFlag = flag.String("source", "", "Specifies the http.FileSystem variable to use as source.")
-	tagFlag    = flag.String("tag", "dev", "Specifies a single build tag to use for source. The output will include a negated version.")
-	nFlag      = flag.Bool("n", false, "Print the generated source but do not run it.")
+	sourceFlag   stringsFlag
+	tagFlag      = flag.String("tag", "dev", `Specifies a build constraint expression, such as "dev" or "dev && !wasm", to use for source. The output will be guarded by its negation.`)
+	nFlag        = flag.Bool("n", false, "Print the generated source but do not run it.")
+	vFlag        = flag.Bool("v", false, "Print the resolved settings of each source, and the go command that's run.")
+	modFlag      = flag.String("mod", "", "Module download mode (readonly, vendor or mod) passed to the go command.")
+	watchFlag    = flag.Bool("watch", false, "Watch sources and generate code again whenever they change, until interrupted.")
+	intervalFlag = flag.Duration("interval", time.Second, "Interval between polls of sources when watching.")
 )
 
+func init() {
+	flag.Var(&sourceFlag, "source", "Specifies the http.FileSystem variable, or function returning one, to use as source. It can be repeated, or be a space-separated list, to generate code for multiple variables at once.")
+}
+
 func usage() {
-	fmt.Fprintln(os.Stderr, `Usage: vfsgendev [flags] -source="import/path".VariableName`)
+	fmt.Fprintln(os.Stderr, `Usage: vfsgendev [flags] -source="import/path".VariableName [-source=...]`)
+	fmt.Fprintln(os.Stderr, `       vfsgendev extract [flags] -source="import/path".VariableName dir`)
 	flag.PrintDefaults()
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "extract" {
+		err := extractMain(os.Args[2:])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	flag.Usage = usage
 	flag.Parse()
 	if flag.NArg() != 0 {
 		flag.Usage()
 		os.Exit(2)
 	}
-	importPath, variableName, err := parseSourceFlag(*sourceFlag)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "-source flag has invalid value:", err)
+	var sources []sourceVar
+	for _, value := range sourceFlag {
+		for _, s := range strings.Fields(value) {
+			importPath, variableName, err := parseSourceFlag(s)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "-source flag has invalid value:", err)
+				fmt.Fprintln(os.Stderr)
+				flag.Usage()
+				os.Exit(2)
+			}
+			sources = append(sources, sourceVar{Source: s, ImportPath: importPath, VariableName: variableName})
+		}
+	}
+	if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "-source flag is required")
 		fmt.Fprintln(os.Stderr)
 		flag.Usage()
 		os.Exit(2)
 	}
-	tag, err := parseTagFlag(*tagFlag)
+	tagExpr, err := parseTagFlag(*tagFlag)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "-tag flag has invalid value:", err)
 		fmt.Fprintln(os.Stderr)
@@ -46,45 +106,233 @@ func main() {
 		os.Exit(2)
 	}
 
-	err = run(importPath, variableName, tag)
+	if *intervalFlag <= 0 {
+		fmt.Fprintln(os.Stderr, "-interval flag has invalid value: must be positive")
+		fmt.Fprintln(os.Stderr)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opt, err := parseOptionFlags()
+	if err == nil {
+		err = checkSingleSourceFlags(len(sources))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = run(sources, tagExpr, opt)
 	if err != nil {
-		log.Fatalln(err)
+		// Print errors without a timestamp, so they start with their position.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
 
-func run(importPath, variableName, tag string) error {
-	bctx := build.Default
-	bctx.BuildTags = []string{tag}
-	packageName, variableComment, err := lookupNameAndComment(bctx, importPath, variableName)
+// sourceVar is a parsed -source flag value.
+type sourceVar struct {
+	Source       string
+	ImportPath   string
+	VariableName string
+}
+
+// run generates code for all sources with a single generator program,
+// which is built and run once. Errors for all sources are reported together.
+func run(sources []sourceVar, tagExpr constraint.Expr, opt options) error {
+	tags, err := satisfyingTags(tagExpr)
 	if err != nil {
 		return err
 	}
+	output, err := absFlag(*outputFlag)
+	if err != nil {
+		return err
+	}
+	manifest, err := absFlag(*manifestFlag)
+	if err != nil {
+		return err
+	}
+	outdir, err := absFlag(*outdirFlag)
+	if err != nil {
+		return err
+	}
+	d := data{Options: opt, Watch: *watchFlag, Interval: *intervalFlag}
+	var errs []string
+	pkgs := make(map[string]*packages.Package) // Import path as specified -> package.
+	imports := make(map[string]string)         // Package path -> import name.
+	filenames := make(map[string]string)       // Output filename -> source.
+	var modDir string
+	for _, s := range sources {
+		pkg, ok := pkgs[s.ImportPath]
+		if !ok {
+			pkg, err = loadPackage(s.ImportPath, tags)
+			if err != nil {
+				errs = append(errs, sourceError(s, err))
+				continue
+			}
+			pkgs[s.ImportPath] = pkg
+		}
+		if modDir == "" {
+			modDir = moduleDir(pkg)
+		}
+		kind, err := classifySource(pkg, s.VariableName)
+		if err != nil {
+			errs = append(errs, sourceError(s, err))
+			continue
+		}
+		variableName := generatedName(s.VariableName, kind)
+
+		filename := output
+		if filename == "" {
+			dir := outdir
+			if dir == "" {
+				dir = packageDir(pkg)
+			}
+			filename = filepath.Join(dir, strings.ToLower(variableName)+"_vfsdata.go")
+		}
+		if other, ok := filenames[filename]; ok {
+			errs = append(errs, sourceError(s, fmt.Errorf("output file %s is also written by %s", filename, other)))
+			continue
+		}
+		filenames[filename] = s.Source
+
+		name, ok := imports[pkg.PkgPath]
+		if !ok {
+			name = fmt.Sprintf("sourcepkg%d", len(d.Imports))
+			imports[pkg.PkgPath] = name
+			d.Imports = append(d.Imports, sourceImport{Name: name, ImportPath: pkg.PkgPath})
+		}
+		comment := *commentFlag
+		if comment == "" {
+			comment = sourceComment(pkg, s.VariableName)
+		}
+		// For a function, the generated code declares the function with the comment,
+		// and the variable it returns, in the "Footer" template.
+		templates := opt.Templates
+		if kind.Call {
+			if definesTemplate(opt.Templates, "Footer") {
+				errs = append(errs, sourceError(s, errors.New(`-templates flag can't define the "Footer" template for a function source, since it declares the generated function`)))
+				continue
+			}
+			if comment == "" {
+				comment = fmt.Sprintf("%s returns the statically implemented virtual filesystem provided to vfsgen.", s.VariableName)
+			}
+			templates += funcTemplate(s.VariableName, kind, comment, variableName)
+			comment = fmt.Sprintf("%s statically implements the virtual filesystem returned by %s.", variableName, s.VariableName)
+		}
+		d.Sources = append(d.Sources, source{
+			Source:           s.Source,
+			Import:           name,
+			PackageName:      pkg.Name,
+			SourceName:       s.VariableName,
+			Kind:             kind,
+			BuildTags:        negatedConstraint(tagExpr),
+			VariableName:     variableName,
+			VariableComment:  comment,
+			TypePrefix:       *typePrefixFlag,
+			Templates:        templates,
+			Dir:              packageDir(pkg),
+			Filename:         filename,
+			ManifestFilename: manifest,
+		})
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	if !opt.RuntimeImport {
+		setTypePrefixes(d.Sources)
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range d.Sources {
+		if !seen[s.Dir] {
+			seen[s.Dir] = true
+			d.Dirs = append(d.Dirs, s.Dir)
+		}
+	}
+	if d.Watch && len(d.Dirs) > 1 {
+		return fmt.Errorf("-watch flag is only valid with sources in a single package directory, have %d directories", len(d.Dirs))
+	}
+
+	if *vFlag {
+		printSettings(os.Stderr, d, tags, modDir)
+	}
 
 	var buf bytes.Buffer
-	err = generateTemplate.Execute(&buf, data{
-		ImportPath:      importPath,
-		PackageName:     packageName,
-		BuildTags:       "!" + tag,
-		VariableName:    variableName,
-		VariableComment: variableComment,
-	})
+	err = generateTemplate.Execute(&buf, d)
 	if err != nil {
 		return err
 	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generator program: %v", err)
+	}
 
 	if *nFlag {
-		io.Copy(os.Stdout, &buf)
+		os.Stdout.Write(src)
 		return nil
 	}
 
-	err = goRun(buf.String(), tag)
-	return err
+	return goRun(string(src), tags, modDir, d.Dirs[0])
+}
+
+// printSettings prints the resolved settings of each source to w.
+func printSettings(w io.Writer, d data, tags []string, modDir string) {
+	fmt.Fprintf(w, "build tags: %s\n", strings.Join(tags, ","))
+	if modDir != "" {
+		fmt.Fprintf(w, "module: %s\n", modDir)
+	}
+	for _, s := range d.Sources {
+		fmt.Fprintf(w, "%s: %s declared at %s\n", s.Source, s.Kind.describe(), s.Kind.Pos)
+		fmt.Fprintf(w, "\tworking directory: %s\n", s.Dir)
+		fmt.Fprintf(w, "\toutput: %s (package %s, variable %s, build constraint %s)\n", s.Filename, s.PackageName, s.VariableName, s.BuildTags)
+		if s.ManifestFilename != "" {
+			fmt.Fprintf(w, "\tmanifest: %s\n", s.ManifestFilename)
+		}
+		if s.TypePrefix != "" {
+			fmt.Fprintf(w, "\ttype prefix: %s\n", s.TypePrefix)
+		}
+	}
+}
+
+// setTypePrefixes sets distinct type prefixes for sources that are generated into
+// the same package, since each output file declares its own types.
+func setTypePrefixes(sources []source) {
+	count := make(map[string]int) // Import name -> number of sources.
+	for _, s := range sources {
+		count[s.Import]++
+	}
+	for i, s := range sources {
+		if count[s.Import] > 1 {
+			sources[i].TypePrefix = "vfsgen۰" + s.VariableName + "۰"
+		}
+	}
 }
 
-// goRun runs Go code src with build tags.
-func goRun(src string, tags string) error {
-	// Create a temp folder.
-	tempDir, err := os.MkdirTemp("", "vfsgendev_")
+// sourceError returns the message of error err for source s. It's at the position
+// of the declaration of the source if known, or otherwise at the position of the
+// go:generate directive that invoked vfsgendev, if any.
+func sourceError(s sourceVar, err error) string {
+	if _, ok := err.(*declError); ok {
+		return err.Error()
+	}
+	if pos, ok := directivePos(); ok {
+		return fmt.Sprintf("%s: %s: %v", pos, s.Source, err)
+	}
+	return fmt.Sprintf("%s: %v", s.Source, err)
+}
+
+// goRun runs Go code src with build tags. If modDir is not empty, the code is
+// placed in a temporary directory inside it, so that it's built as part of that
+// module, with its requirements, replace directives and vendored packages.
+// It's run in directory dir, or the current directory if dir is empty.
+func goRun(src string, tags []string, modDir, dir string) error {
+	// Create a temp folder. Its name starts with "." so that
+	// the go command ignores it when matching package patterns.
+	tempDir, err := os.MkdirTemp(modDir, ".vfsgendev_")
 	if err != nil {
 		return err
 	}
@@ -103,8 +351,23 @@ func goRun(src string, tags string) error {
 	}
 
 	// Compile and run the program.
-	cmd := exec.Command("go", "run", "-tags="+tags, tempFile)
+	args := append([]string{"run"}, buildFlags(tags)...)
+	cmd := exec.Command("go", append(args, tempFile)...)
+	cmd.Dir = dir
+	if *vFlag {
+		fmt.Fprintf(os.Stderr, "running %s in %s\n", strings.Join(cmd.Args, " "), dir)
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
+
+// stringsFlag is a flag that can be repeated to specify multiple values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, " ") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
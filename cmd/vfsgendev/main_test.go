@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+	"github.com/shurcooL/vfsgen"
+)
+
+// Verify that vfsgendev generates code that replaces the sources in a module
+// that requires vfsgen, when the dev build tag is not set.
+func TestVfsgendev(t *testing.T) {
+	vfsgendev := buildVfsgendev(t)
+	dir := newModule(t)
+
+	out := runCommand(t, dir, vfsgendev, `-source="example.com/assets".Assets`, `-source="example.com/assets".NewDocs`)
+	if strings.Contains(out, "warning") {
+		t.Errorf("got output with a warning:\n%s", out)
+	}
+
+	// The package builds with the sources and with the generated code.
+	for _, tags := range []string{"dev", ""} {
+		runCommand(t, dir, "go", "vet", "-tags="+tags, ".")
+	}
+	for _, tc := range []struct {
+		filename, path, want string
+	}{
+		{"assets_vfsdata.go", "/hello.txt", "Hello, world!\n"},
+		{"docs_vfsdata.go", "/index.md", "# Docs\n"},
+	} {
+		filename := filepath.Join(dir, tc.filename)
+		fs, err := vfsgen.LoadGenerated(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := vfsutil.ReadFile(fs, tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != tc.want {
+			t.Errorf("%s: got %s content %q, want %q", tc.filename, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	s := sourceVar{Source: `"example.com/assets".Assets`, ImportPath: "example.com/assets", VariableName: "Assets"}
+	decl := &declError{pos: "assets.go:5:5", msg: "Assets is not a variable or function"}
+	tests := []struct {
+		file, line string
+		err        error
+		want       string
+	}{
+		{err: decl, want: "assets.go:5:5: Assets is not a variable or function"},
+		{file: "gen.go", line: "3", err: decl, want: "assets.go:5:5: Assets is not a variable or function"},
+		{err: errors.New("no Go files"), want: `"example.com/assets".Assets: no Go files`},
+		{file: "gen.go", line: "3", err: errors.New("no Go files"), want: `gen.go:3: "example.com/assets".Assets: no Go files`},
+	}
+	for _, tc := range tests {
+		t.Setenv("GOFILE", tc.file)
+		t.Setenv("GOLINE", tc.line)
+		if got := sourceError(s, tc.err); got != tc.want {
+			t.Errorf("sourceError with GOFILE=%q GOLINE=%q: got %q, want %q", tc.file, tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestSetTypePrefixes(t *testing.T) {
+	sources := []source{
+		{Import: "sourcepkg0", VariableName: "Assets"},
+		{Import: "sourcepkg1", VariableName: "Templates", TypePrefix: "tmpl"},
+		{Import: "sourcepkg0", VariableName: "docs", TypePrefix: "docs"},
+	}
+	setTypePrefixes(sources)
+	// Sources in the same package get prefixes derived from their variable names,
+	// which replace the one set by the -type-prefix flag.
+	want := []string{"vfsgen۰Assets۰", "tmpl", "vfsgen۰docs۰"}
+	for i, s := range sources {
+		if s.TypePrefix != want[i] {
+			t.Errorf("%s: got type prefix %q, want %q", s.VariableName, s.TypePrefix, want[i])
+		}
+	}
+}
+
+func TestPrintSettings(t *testing.T) {
+	d := data{Sources: []source{
+		{
+			Source:       `"example.com/assets".Assets`,
+			Kind:         sourceKind{Type: "http.Dir", Pos: "assets.go:11:5"},
+			Dir:          "/src/assets",
+			PackageName:  "assets",
+			BuildTags:    "!dev",
+			VariableName: "Assets",
+			Filename:     "assets_vfsdata.go",
+		},
+		{
+			Source:           `"example.com/assets".NewDocs`,
+			Kind:             sourceKind{Call: true, ReturnsError: true, Type: "func() (http.FileSystem, error)", Pos: "docs.go:9:6"},
+			Dir:              "/src/assets",
+			PackageName:      "assets",
+			BuildTags:        "!dev",
+			VariableName:     "docs",
+			TypePrefix:       "docs",
+			Filename:         "docs_vfsdata.go",
+			ManifestFilename: "docs_manifest.json",
+		},
+	}}
+	var buf bytes.Buffer
+	printSettings(&buf, d, []string{"dev"}, "/src")
+	want := `build tags: dev
+module: /src
+"example.com/assets".Assets: variable of type http.Dir declared at assets.go:11:5
+	working directory: /src/assets
+	output: assets_vfsdata.go (package assets, variable Assets, build constraint !dev)
+"example.com/assets".NewDocs: function of type func() (http.FileSystem, error) declared at docs.go:9:6
+	working directory: /src/assets
+	output: docs_vfsdata.go (package assets, variable docs, build constraint !dev)
+	manifest: docs_manifest.json
+	type prefix: docs
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// Verify that the extract subcommand writes out the generated filesystem, or the
+// source filesystem with the dev build tag.
+func TestVfsgendev_extract(t *testing.T) {
+	vfsgendev := buildVfsgendev(t)
+	dir := newModule(t)
+	runCommand(t, dir, vfsgendev, `-source="example.com/assets".Assets`, `-source="example.com/assets".NewDocs`)
+
+	repeated := strings.Repeat("This text compresses easily.\n", 40)
+	for _, tc := range []struct {
+		args  []string
+		files map[string]string // Name -> content, or "gzip" for compressed content.
+	}{
+		{nil, map[string]string{"hello.txt": "Hello, world!\n", "repeated.txt": repeated}},
+		{[]string{"-gz"}, map[string]string{"hello.txt": "Hello, world!\n", "repeated.txt.gz": repeated}},
+		{[]string{"-tags=dev", "-gz"}, map[string]string{"hello.txt": "Hello, world!\n", "repeated.txt": repeated}},
+	} {
+		out := t.TempDir()
+		args := append(append([]string{"extract"}, tc.args...), `-source="example.com/assets".Assets`, out)
+		runCommand(t, dir, vfsgendev, args...)
+
+		entries, err := os.ReadDir(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(entries), len(tc.files); got != want {
+			t.Errorf("%q: got %d files, want %d", tc.args, got, want)
+		}
+		for name, want := range tc.files {
+			b, err := os.ReadFile(filepath.Join(out, name))
+			if err != nil {
+				t.Errorf("%q: %v", tc.args, err)
+				continue
+			}
+			if strings.HasSuffix(name, ".gz") {
+				gr, err := gzip.NewReader(bytes.NewReader(b))
+				if err != nil {
+					t.Errorf("%q: %s: %v", tc.args, name, err)
+					continue
+				}
+				b, err = io.ReadAll(gr)
+				if err != nil {
+					t.Errorf("%q: %s: %v", tc.args, name, err)
+					continue
+				}
+			}
+			if got := string(b); got != want {
+				t.Errorf("%q: got %s content %q, want %q", tc.args, name, got, want)
+			}
+		}
+	}
+}
+
+// Verify that vfsgendev warns when the options leave no files in the generated filesystem.
+func TestVfsgendev_noFiles(t *testing.T) {
+	vfsgendev := buildVfsgendev(t)
+	dir := newModule(t)
+
+	out := runCommand(t, dir, vfsgendev, "-include=*.html", `-source="example.com/assets".Assets`)
+	if want := `warning: "example.com/assets".Assets: generated filesystem has no files`; !strings.Contains(out, want) {
+		t.Errorf("got output %q, want it to contain %q", out, want)
+	}
+}
+
+// buildVfsgendev builds vfsgendev, and returns the filename of the binary.
+func buildVfsgendev(t *testing.T) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "vfsgendev")
+	runCommand(t, ".", "go", "build", "-o", filename, ".")
+	return filename
+}
+
+// newModule copies the package in testdata/assets to the root of a new module
+// that requires this copy of vfsgen, and returns the directory of the module.
+func newModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := filepath.WalkDir(filepath.Join("testdata", "assets"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Join("testdata", "assets"), path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0755)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), b, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goMod := fmt.Sprintf("module example.com/assets\n\ngo 1.22\n\nrequire github.com/shurcooL/vfsgen v0.0.0\n\nreplace github.com/shurcooL/vfsgen => %q\n", root)
+	err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Start from the checksums of vfsgen's requirements, which the module shares.
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, dir, "go", "mod", "tidy")
+	return dir
+}
+
+// runCommand runs the named program with args in directory dir,
+// and returns its combined output.
+func runCommand(t *testing.T, dir, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shurcooL/vfsgen"
+	"github.com/shurcooL/vfsgen/internal/optparse"
+)
+
+// Flags for vfsgen options. PackageName, VariableName and BuildTags are determined
+// by the -source and -tag flags. Conflict doesn't apply, since each source is
+// a single filesystem.
+var (
+	outputFlag        = flag.String("o", "", `Filename of the generated Go code output. Only valid with a single source. (default "{{toLower VariableName}}_vfsdata.go" in -outdir)`)
+	outdirFlag        = flag.String("outdir", "", "Directory to write generated Go code to. (default the directory of the source package)")
+	manifestFlag      = flag.String("manifest", "", "Filename of an optional JSON manifest to write alongside the generated Go code. Only valid with a single source.")
+	commentFlag       = flag.String("comment", "", "Comment of the variable in the generated code. Only valid with a single source. (default the doc comment of the source variable)")
+	typePrefixFlag    = flag.String("type-prefix", "", "Prefix of the names of the types declared in the generated code. Only valid with a single source.")
+	runtimeFlag       = flag.Bool("runtime", false, "Import the vfsgenrt package for the implementation of the filesystem, instead of declaring types.")
+	pathConstantsFlag = flag.Bool("path-constants", false, "Generate a path type with a constant for each file and directory.")
+	noCompressFlag    = flag.Bool("no-compress", false, "Store all files uncompressed.")
+	levelFlag         = flag.Int("level", 0, "Gzip compression level, from 1 (best speed) to 9 (best compression). (default 9)")
+	cacheFlag         = flag.String("cache", "", "Directory in which to cache compressed file contents across runs.")
+	minifyFlag        = flag.String("minify", "", `Comma-separated list of file types to minify: "json", "css", "html", "svg" or "all".`)
+	templatesFlag     = flag.String("templates", "", "File with template definitions that override the templates used to produce the generated code.")
+	stripFlag         = flag.String("strip", "", "Path of a directory in the source; only its contents are included, with the path removed.")
+	prefixFlag        = flag.String("prefix", "", "Path added to the start of all paths in the generated filesystem.")
+	ignoreFileFlag    = flag.Bool("ignore-file", false, "Read a .vfsgenignore file with gitignore syntax from the root of the source.")
+	modTimeFlag       = flag.String("modtime", "keep", `Modification times in the generated code: "keep" or "zero".`)
+	maxTotalSizeFlag  = flag.Int64("max-total-size", 0, "Maximum total stored size of all files, in bytes. (default no limit)")
+	maxFileSizeFlag   = flag.Int64("max-file-size", 0, "Maximum stored size of each file, in bytes. (default no limit)")
+	includeFlag       stringsFlag
+	excludeFlag       stringsFlag
+	limitFlag         stringsFlag
+)
+
+func init() {
+	flag.Var(&includeFlag, "include", "Pattern of files to include; can be repeated. (default all files)")
+	flag.Var(&excludeFlag, "exclude", "Pattern of files and directories to exclude; can be repeated.")
+	flag.Var(&limitFlag, "limit", `Maximum total stored size of the files that match a pattern, as "pattern=bytes"; can be repeated.`)
+}
+
+// options are the vfsgen options that apply to all sources.
+type options struct {
+	RuntimeImport    bool
+	PathConstants    bool
+	NoCompression    bool
+	CompressionLevel int
+	CacheDir         string
+	Transform        []string // Names of vfsgen Transform variables.
+	Templates        string
+	StripPrefix      string
+	MountPrefix      string
+	Include          []string
+	Exclude          []string
+	IgnoreFile       bool
+	ModTime          string // Name of a vfsgen ModTimePolicy constant, or empty for the default.
+	MaxTotalSize     int64
+	MaxFileSize      int64
+	Limits           []vfsgen.BudgetLimit
+}
+
+// parseOptionFlags returns the options specified by flags.
+func parseOptionFlags() (options, error) {
+	cacheDir, err := absFlag(*cacheFlag)
+	if err != nil {
+		return options{}, err
+	}
+	opt := options{
+		RuntimeImport:    *runtimeFlag,
+		PathConstants:    *pathConstantsFlag,
+		NoCompression:    *noCompressFlag,
+		CompressionLevel: *levelFlag,
+		CacheDir:         cacheDir,
+		StripPrefix:      *stripFlag,
+		MountPrefix:      *prefixFlag,
+		Include:          includeFlag,
+		Exclude:          excludeFlag,
+		IgnoreFile:       *ignoreFileFlag,
+		MaxTotalSize:     *maxTotalSizeFlag,
+		MaxFileSize:      *maxFileSizeFlag,
+	}
+
+	for _, l := range limitFlag {
+		limit, err := optparse.ParseLimit(l)
+		if err != nil {
+			return options{}, fmt.Errorf("-limit flag has invalid value: %v", err)
+		}
+		opt.Limits = append(opt.Limits, limit)
+	}
+
+	modTime, err := optparse.ParseModTime(*modTimeFlag)
+	if err != nil {
+		return options{}, fmt.Errorf("-modtime flag has invalid value: %v", err)
+	}
+	if modTime.Policy != vfsgen.ModTimeKeep {
+		opt.ModTime = modTime.Name
+	}
+
+	if *minifyFlag != "" {
+		ms, err := optparse.ParseMinify(strings.Split(*minifyFlag, ","))
+		if err != nil {
+			return options{}, fmt.Errorf("-minify flag has invalid value: %v", err)
+		}
+		for _, m := range ms {
+			opt.Transform = append(opt.Transform, m.Name)
+		}
+	}
+
+	if *templatesFlag != "" {
+		b, err := os.ReadFile(*templatesFlag)
+		if err != nil {
+			return options{}, err
+		}
+		opt.Templates = string(b)
+	}
+
+	return opt, nil
+}
+
+// checkSingleSourceFlags returns an error if a flag that's only valid
+// with a single source is set, and there are n sources.
+func checkSingleSourceFlags(n int) error {
+	if n <= 1 {
+		return nil
+	}
+	for _, f := range []string{"o", "manifest", "comment", "type-prefix"} {
+		if flag.Lookup(f).Value.String() != "" {
+			return fmt.Errorf("-%s flag is only valid with a single source", f)
+		}
+	}
+	return nil
+}
+
+// absFlag returns the absolute path of the filename in a flag value, or "" if it's empty.
+func absFlag(filename string) (string, error) {
+	if filename == "" {
+		return "", nil
+	}
+	return filepath.Abs(filename)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+func TestParseOptionFlags(t *testing.T) {
+	templates := filepath.Join(t.TempDir(), "templates.tmpl")
+	err := os.WriteFile(templates, []byte(`{{define "Header"}}{{end}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheDir, err := filepath.Abs("cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args    []string
+		want    options
+		wantErr string
+	}{
+		{
+			args: nil,
+		},
+		{
+			args: []string{
+				"-runtime", "-path-constants", "-no-compress", "-level=1", "-cache=cache",
+				"-strip=/public", "-prefix=/static", "-ignore-file",
+				"-include=*.html", "-include=*.css", "-exclude=*.map",
+				"-templates=" + templates,
+			},
+			want: options{
+				RuntimeImport:    true,
+				PathConstants:    true,
+				NoCompression:    true,
+				CompressionLevel: 1,
+				CacheDir:         cacheDir,
+				Templates:        `{{define "Header"}}{{end}}`,
+				StripPrefix:      "/public",
+				MountPrefix:      "/static",
+				Include:          []string{"*.html", "*.css"},
+				Exclude:          []string{"*.map"},
+				IgnoreFile:       true,
+			},
+		},
+		{
+			args: []string{"-minify=json, css", "-modtime=zero"},
+			want: options{Transform: []string{"MinifyJSON", "MinifyCSS"}, ModTime: "ModTimeZero"},
+		},
+		{
+			args: []string{"-minify=all"},
+			want: options{Transform: []string{"MinifyJSON", "MinifyCSS", "MinifyHTML", "MinifySVG"}},
+		},
+		{
+			args: []string{"-max-total-size=1000", "-max-file-size=100", "-limit=*.png=10", "-limit=a=b=20"},
+			want: options{MaxTotalSize: 1000, MaxFileSize: 100, Limits: []vfsgen.BudgetLimit{{Pattern: "*.png", MaxSize: 10}, {Pattern: "a=b", MaxSize: 20}}},
+		},
+		{args: []string{"-minify=xml"}, wantErr: `-minify flag has invalid value: unknown file type "xml"`},
+		{args: []string{"-modtime=now"}, wantErr: `-modtime flag has invalid value: unknown modification time policy "now"`},
+		{args: []string{"-limit=*.png"}, wantErr: `-limit flag has invalid value: "*.png" is not of the form pattern=bytes`},
+		{args: []string{"-limit=*.png=big"}, wantErr: `-limit flag has invalid value:`},
+		{args: []string{"-templates=" + filepath.Join(t.TempDir(), "missing.tmpl")}, wantErr: "no such file or directory"},
+	}
+	for _, tc := range tests {
+		setFlags(t, tc.args...)
+		got, err := parseOptionFlags()
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%q: got error %v, want one containing %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %+v, want %+v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestCheckSingleSourceFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		n       int
+		wantErr string
+	}{
+		{args: nil, n: 2},
+		{args: []string{"-o=assets_vfsdata.go", "-manifest=m.json", "-comment=c", "-type-prefix=p"}, n: 1},
+		{args: []string{"-runtime", "-outdir=gen", "-include=*.html"}, n: 3},
+		{args: []string{"-o=assets_vfsdata.go"}, n: 2, wantErr: "-o flag is only valid with a single source"},
+		{args: []string{"-manifest=m.json"}, n: 2, wantErr: "-manifest flag is only valid with a single source"},
+		{args: []string{"-comment=c"}, n: 2, wantErr: "-comment flag is only valid with a single source"},
+		{args: []string{"-type-prefix=p"}, n: 2, wantErr: "-type-prefix flag is only valid with a single source"},
+	}
+	for _, tc := range tests {
+		setFlags(t, tc.args...)
+		err := checkSingleSourceFlags(tc.n)
+		if got := errString(err); got != tc.wantErr {
+			t.Errorf("%q with %d sources: got error %q, want %q", tc.args, tc.n, got, tc.wantErr)
+		}
+	}
+}
+
+// setFlags resets the flags of vfsgendev to their defaults, and parses args.
+// Flags are reset again when the test finishes.
+func setFlags(t *testing.T, args ...string) {
+	t.Helper()
+	resetFlags()
+	t.Cleanup(resetFlags)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resetFlags() {
+	flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "test.") {
+			return
+		}
+		if v, ok := f.Value.(*stringsFlag); ok {
+			*v = nil
+			return
+		}
+		f.Value.Set(f.DefValue)
+	})
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
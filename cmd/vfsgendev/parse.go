@@ -4,8 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
-	"go/build"
-	"go/doc"
+	"go/build/constraint"
 	"go/parser"
 	"go/printer"
 	"go/token"
@@ -13,10 +12,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 // parseSourceFlag parses the "-source" flag value. It must have "import/path".VariableName format.
-// It returns an error if the parsed import path is relative.
+// The import path may be relative to the current directory.
 func parseSourceFlag(sourceFlag string) (importPath, variableName string, err error) {
 	// Parse sourceFlag as a Go expression, albeit a strange one:
 	//
@@ -34,11 +35,6 @@ func parseSourceFlag(sourceFlag string) (importPath, variableName string, err er
 	if err != nil {
 		return "", "", fmt.Errorf("invalid format, expression %v is not a properly quoted Go string: %v", stringifyAST(se.X), err)
 	}
-	if build.IsLocalImport(importPath) {
-		// Generated code is executed in a temporary directory,
-		// and can't use relative import paths. So disallow them.
-		return "", "", fmt.Errorf("relative import paths are not supported")
-	}
 	variableName = se.Sel.Name
 	return importPath, variableName, nil
 }
@@ -55,37 +51,145 @@ func stringValue(e ast.Expr) (string, error) {
 	return strconv.Unquote(lit.Value)
 }
 
-// parseTagFlag parses the "-tag" flag value. It must be a single build tag.
-func parseTagFlag(tagFlag string) (tag string, err error) {
-	tags := strings.Fields(tagFlag)
-	if len(tags) != 1 {
-		return "", fmt.Errorf("%q is not a valid single build tag, but %q", tagFlag, tags)
+// parseTagFlag parses the "-tag" flag value. It must be a build constraint
+// expression, in the syntax of a //go:build line without the "//go:build" prefix.
+func parseTagFlag(tagFlag string) (constraint.Expr, error) {
+	expr, err := constraint.Parse("//go:build " + tagFlag)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid build constraint expression: %v", tagFlag, err)
 	}
-	return tags[0], nil
+	return expr, nil
 }
 
-// lookupNameAndComment imports package using provided build context, and
-// returns the package name and variable comment.
-func lookupNameAndComment(bctx build.Context, importPath, variableName string) (packageName, variableComment string, err error) {
-	wd, err := os.Getwd()
-	if err != nil {
-		return "", "", err
+// loadPackage loads the package with the given import path using build tags,
+// in the module context of the current directory. The go command is invoked the
+// same way as by go build, so go.work, vendoring and GOFLAGS are honored.
+// Dependencies are type-checked from source rather than read from export data,
+// whose format may be newer than the one that go/packages can read.
+func loadPackage(importPath string, tags []string) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedModule,
+		BuildFlags: buildFlags(tags),
 	}
-	bpkg, err := bctx.Import(importPath, wd, 0)
+	pkgs, err := packages.Load(cfg, importPath)
 	if err != nil {
-		return "", "", fmt.Errorf("can't import package %q: %v", importPath, err)
+		return nil, fmt.Errorf("can't load package %q: %v", importPath, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("import path %q matches %d packages, want 1", importPath, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		// Report all errors, which are at positions in the package's files if known.
+		// Errors from the go command duplicate parse and type errors, so skip them if there are any.
+		hasTypeErrors := false
+		for _, e := range pkg.Errors {
+			hasTypeErrors = hasTypeErrors || e.Kind != packages.ListError
+		}
+		var msgs []string
+		for _, e := range pkg.Errors {
+			if hasTypeErrors && e.Kind == packages.ListError {
+				continue
+			}
+			if e.Pos == "" || e.Pos == "-" {
+				msgs = append(msgs, e.Msg)
+				continue
+			}
+			msgs = append(msgs, relativePos(e.Pos)+": "+e.Msg)
+		}
+		return nil, fmt.Errorf("can't load package %q:\n\t%s", importPath, strings.Join(msgs, "\n\t"))
 	}
-	dpkg, err := computeDoc(bpkg)
+	return pkg, nil
+}
+
+// relativePos returns position pos, of the form "file:line:col",
+// with file relative to the current directory if it's below it.
+func relativePos(pos string) string {
+	wd, err := os.Getwd()
 	if err != nil {
-		return "", "", fmt.Errorf("can't get godoc of package %q: %v", importPath, err)
+		return pos
+	}
+	rel, err := filepath.Rel(wd, pos)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return pos
+	}
+	return rel
+}
+
+// directivePos returns the position of the go:generate directive that
+// invoked vfsgendev, if any.
+func directivePos() (string, bool) {
+	file, line := os.Getenv("GOFILE"), os.Getenv("GOLINE")
+	if file == "" || line == "" {
+		return "", false
+	}
+	return file + ":" + line, true
+}
+
+// buildFlags returns the flags to pass to the go command for build tags and the -mod flag.
+func buildFlags(tags []string) []string {
+	var flags []string
+	if len(tags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(tags, ","))
+	}
+	if *modFlag != "" {
+		flags = append(flags, "-mod="+*modFlag)
 	}
-	for _, v := range dpkg.Vars {
-		if len(v.Names) == 1 && v.Names[0] == variableName {
-			variableComment = strings.TrimSuffix(v.Doc, "\n")
-			break
+	return flags
+}
+
+// sourceComment returns the doc comment of the variable or function named name in pkg, if any.
+func sourceComment(pkg *packages.Package, name string) string {
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == name {
+					return strings.TrimSuffix(decl.Doc.Text(), "\n")
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if !declares(vs, name) {
+						continue
+					}
+					doc := vs.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					return strings.TrimSuffix(doc.Text(), "\n")
+				}
+			}
 		}
 	}
-	return bpkg.Name, variableComment, nil
+	return ""
+}
+
+// declares reports whether vs declares a variable named name.
+func declares(vs *ast.ValueSpec, name string) bool {
+	for _, id := range vs.Names {
+		if id.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// packageDir returns the directory of pkg.
+func packageDir(pkg *packages.Package) string {
+	return filepath.Dir(pkg.GoFiles[0])
+}
+
+// moduleDir returns the root directory of the main module that provides pkg,
+// or "" if pkg is not in a main module.
+func moduleDir(pkg *packages.Package) string {
+	if pkg.Module == nil || !pkg.Module.Main {
+		return ""
+	}
+	return pkg.Module.Dir
 }
 
 func stringifyAST(node interface{}) string {
@@ -96,21 +200,3 @@ func stringifyAST(node interface{}) string {
 	}
 	return buf.String()
 }
-
-// computeDoc computes the package documentation for the given package.
-func computeDoc(bpkg *build.Package) (*doc.Package, error) {
-	fset := token.NewFileSet()
-	files := make(map[string]*ast.File)
-	for _, file := range append(bpkg.GoFiles, bpkg.CgoFiles...) {
-		f, err := parser.ParseFile(fset, filepath.Join(bpkg.Dir, file), nil, parser.ParseComments)
-		if err != nil {
-			return nil, err
-		}
-		files[file] = f
-	}
-	apkg := &ast.Package{
-		Name:  bpkg.Name,
-		Files: files,
-	}
-	return doc.New(apkg, bpkg.ImportPath, 0), nil
-}
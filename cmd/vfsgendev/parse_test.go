@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestSourceComment(t *testing.T) {
+	pkg := loadTestdata(t, "sources")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"A", "A and B are declared together."},
+		{"B", "A and B are declared together."},
+		{"C", "C has its own comment."},
+		{"D", ""},
+		{"NewE", "NewE returns a filesystem."},
+		{"F", ""},
+		{"K", ""},
+	}
+	for _, tc := range tests {
+		if got := sourceComment(pkg, tc.name); got != tc.want {
+			t.Errorf("sourceComment(%q): got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseSourceFlag(t *testing.T) {
+	tests := []struct {
+		in               string
+		wantImportPath   string
+		wantVariableName string
+		wantErr          bool
+	}{
+		{in: `"example.com/project/data".Assets`, wantImportPath: "example.com/project/data", wantVariableName: "Assets"},
+		{in: `"./data".NewAssets`, wantImportPath: "./data", wantVariableName: "NewAssets"},
+		{in: `example.com/project/data.Assets`, wantErr: true},
+		{in: `"example.com/project/data"`, wantErr: true},
+		{in: `data.Assets`, wantErr: true},
+	}
+	for _, tc := range tests {
+		importPath, variableName, err := parseSourceFlag(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("parseSourceFlag(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if importPath != tc.wantImportPath || variableName != tc.wantVariableName {
+			t.Errorf("parseSourceFlag(%q): got %q, %q, want %q, %q", tc.in, importPath, variableName, tc.wantImportPath, tc.wantVariableName)
+		}
+	}
+}
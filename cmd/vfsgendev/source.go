@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// sourceKind describes how the generator program gets an http.FileSystem from a source.
+type sourceKind struct {
+	Call         bool // The source is a function to call.
+	ReturnsError bool // The function returns an error as its second result.
+
+	Type string // Type of the source, for -v output.
+	Pos  string // Position of the declaration of the source.
+}
+
+// declError is an error about the declaration of a source, at position pos.
+type declError struct {
+	pos string
+	msg string
+}
+
+func (e *declError) Error() string { return e.pos + ": " + e.msg }
+
+// classifySource type-checks the source named name in pkg. It must be a variable of
+// a type that implements http.FileSystem, or a function without parameters that
+// returns an http.FileSystem, optionally followed by an error. The generated code
+// declares an http.FileSystem variable, or a function with the same signature,
+// so other types couldn't be used in place of the source.
+func classifySource(pkg *packages.Package, name string) (sourceKind, error) {
+	obj := pkg.Types.Scope().Lookup(name)
+	if obj == nil {
+		return sourceKind{}, fmt.Errorf("%s is not declared in package %s", name, pkg.PkgPath)
+	}
+	kind := sourceKind{
+		Type: typeString(pkg, obj.Type()),
+		Pos:  relativePos(pkg.Fset.Position(obj.Pos()).String()),
+	}
+	switch obj := obj.(type) {
+	case *types.Var:
+		switch isFS, ok := fileSystemType(obj.Type()); {
+		case ok && isFS:
+			return sourceKind{}, &declError{kind.Pos, fmt.Sprintf("%s is a variable of type %s, which implements fs.FS but not http.FileSystem, "+
+				"so the generated http.FileSystem variable can't be used in its place; declare it as an http.FileSystem with http.FS", name, kind.Type)}
+		case !ok:
+			return sourceKind{}, &declError{kind.Pos, fmt.Sprintf("%s is a variable of type %s, which doesn't implement http.FileSystem", name, kind.Type)}
+		}
+		return kind, nil
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		res := sig.Results()
+		if sig.Params().Len() == 0 && (res.Len() == 1 || res.Len() == 2 && isError(res.At(1).Type())) &&
+			isNamed(res.At(0).Type(), "net/http", "FileSystem") {
+			kind.Call, kind.ReturnsError = true, res.Len() == 2
+			return kind, nil
+		}
+		return sourceKind{}, &declError{kind.Pos, fmt.Sprintf("%s is a function of type %s, want func() http.FileSystem or func() (http.FileSystem, error), "+
+			"since the generated function has the same signature", name, kind.Type)}
+	default:
+		return sourceKind{}, &declError{kind.Pos, fmt.Sprintf("%s is not a variable or function", name)}
+	}
+}
+
+// describe returns a description of the source, for -v output.
+func (k sourceKind) describe() string {
+	if k.Call {
+		return fmt.Sprintf("function of type %s", k.Type)
+	}
+	return fmt.Sprintf("variable of type %s", k.Type)
+}
+
+// fileSystemType reports whether t implements http.FileSystem or fs.FS, and if so,
+// whether it's an fs.FS. Both have an Open(string) method and differ by its first result.
+func fileSystemType(t types.Type) (isFS, ok bool) {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "Open")
+	open, _ := obj.(*types.Func)
+	if open == nil {
+		return false, false
+	}
+	sig := open.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || !types.Identical(sig.Params().At(0).Type(), types.Typ[types.String]) ||
+		sig.Results().Len() != 2 || !isError(sig.Results().At(1).Type()) {
+		return false, false
+	}
+	switch file := sig.Results().At(0).Type(); {
+	case isNamed(file, "net/http", "File"):
+		return false, true
+	case isNamed(file, "io/fs", "File"):
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// generatedName returns the name of the variable to generate for a source named name.
+// For a variable, it's the same name. For a function, which the generated code
+// declares to return the variable, it's the unexported name of the function
+// without a "New" prefix if it has one, or otherwise with an "FS" suffix.
+func generatedName(name string, kind sourceKind) string {
+	if !kind.Call {
+		return name
+	}
+	if s := strings.TrimPrefix(name, "New"); s != name && s != "" && strings.ToUpper(s[:1]) == s[:1] {
+		name = s
+	} else {
+		name += "FS"
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// definesTemplate reports whether template text defines the template named name.
+func definesTemplate(text, name string) bool {
+	t := parse.New("")
+	t.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	_, err := t.Parse(text, "", "", trees)
+	return err == nil && trees[name] != nil
+}
+
+// funcTemplate returns the definition of the "Footer" template, which declares
+// function name of a source of kind, with doc comment comment, that returns
+// the generated variable named variableName.
+func funcTemplate(name string, kind sourceKind, comment, variableName string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "{{define \"Footer\"}}\n{{comment %s}}\n", strconv.Quote(comment))
+	if kind.ReturnsError {
+		fmt.Fprintf(&buf, "func %s() (http.FileSystem, error) {\n\treturn %s, nil\n}\n", name, variableName)
+	} else {
+		fmt.Fprintf(&buf, "func %s() http.FileSystem {\n\treturn %s\n}\n", name, variableName)
+	}
+	buf.WriteString("{{end}}")
+	return buf.String()
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+func isNamed(t types.Type, pkgPath, name string) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == pkgPath && n.Obj().Name() == name
+}
+
+// typeString returns the string of t, with types in pkg unqualified,
+// and types in other packages qualified by package name.
+func typeString(pkg *packages.Package, t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		return p.Name()
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestClassifySource(t *testing.T) {
+	pkg := loadTestdata(t, "sources")
+	const file = "testdata/sources/sources.go"
+	tests := []struct {
+		name    string
+		want    sourceKind
+		wantErr string
+	}{
+		{name: "A", want: sourceKind{Type: "http.Dir", Pos: file + ":11:5"}},
+		{name: "B", wantErr: file + ":11:8: B is a variable of type int, which doesn't implement http.FileSystem"},
+		{name: "C", want: sourceKind{Type: "http.FileSystem", Pos: file + ":15:2"}},
+		{name: "D", wantErr: file + ":17:2: D is a variable of type fs.FS, which implements fs.FS but not http.FileSystem"},
+		{name: "NewE", want: sourceKind{Call: true, ReturnsError: true, Type: "func() (http.FileSystem, error)", Pos: file + ":21:6"}},
+		{name: "F", want: sourceKind{Call: true, Type: "func() http.FileSystem", Pos: file + ":23:6"}},
+		{name: "G", wantErr: file + ":25:6: G is a function of type func(dir string) http.FileSystem, want func() http.FileSystem"},
+		{name: "H", wantErr: file + ":27:6: H is a function of type func() http.Dir, want"},
+		{name: "I", wantErr: file + ":29:6: I is a function of type func() fs.FS, want"},
+		{name: "J", wantErr: file + ":31:7: J is not a variable or function"},
+		{name: "K", wantErr: "K is not declared in package github.com/shurcooL/vfsgen/cmd/vfsgendev/testdata/sources"},
+	}
+	for _, tc := range tests {
+		got, err := classifySource(pkg, tc.name)
+		if tc.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+				t.Errorf("%s: got error %v, want one starting with %q", tc.name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGeneratedName(t *testing.T) {
+	tests := []struct {
+		name string
+		call bool
+		want string
+	}{
+		{"Assets", false, "Assets"},
+		{"assets", false, "assets"},
+		{"NewAssets", true, "assets"},
+		{"Assets", true, "assetsFS"},
+		{"New", true, "newFS"},
+		{"Newsletter", true, "newsletterFS"},
+	}
+	for _, tc := range tests {
+		if got := generatedName(tc.name, sourceKind{Call: tc.call}); got != tc.want {
+			t.Errorf("generatedName(%q, call %v): got %q, want %q", tc.name, tc.call, got, tc.want)
+		}
+	}
+}
+
+func TestLoadPackage_errors(t *testing.T) {
+	_, err := loadPackage("./testdata/broken", nil)
+	if want := "testdata/broken/broken.go:5:30: cannot use \"assets\""; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+// testdataPackages are the packages loaded by loadTestdata, by name.
+var testdataPackages = make(map[string]*packages.Package)
+
+// loadTestdata loads the package in directory testdata/name.
+func loadTestdata(t *testing.T, name string) *packages.Package {
+	t.Helper()
+	if pkg, ok := testdataPackages[name]; ok {
+		return pkg
+	}
+	pkg, err := loadPackage("./testdata/"+name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdataPackages[name] = pkg
+	return pkg
+}
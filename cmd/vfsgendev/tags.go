@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"math/bits"
+	"sort"
+)
+
+// maxFreeTags is the maximum number of tags in a build constraint expression
+// that satisfyingTags considers.
+const maxFreeTags = 16
+
+// satisfyingTags returns a smallest set of build tags that, in addition to the
+// tags implied by the build environment (GOOS, GOARCH, cgo, Go release tags),
+// satisfies expr. It's the set of tags to pass to the go command via -tags.
+func satisfyingTags(expr constraint.Expr) ([]string, error) {
+	env := environmentTags(build.Default)
+	var free []string // Tags in expr that are not implied by the build environment.
+	seen := make(map[string]bool)
+	expr.Eval(func(tag string) bool {
+		if !env[tag] && !seen[tag] {
+			seen[tag] = true
+			free = append(free, tag)
+		}
+		return false
+	})
+	sort.Strings(free)
+	if len(free) > maxFreeTags {
+		return nil, fmt.Errorf("build constraint %q has more than %d tags", expr, maxFreeTags)
+	}
+
+	// Try sets of tags in order of increasing size.
+	for size := 0; size <= len(free); size++ {
+		for set := 0; set < 1<<len(free); set++ {
+			if bits.OnesCount(uint(set)) != size {
+				continue
+			}
+			ok := expr.Eval(func(tag string) bool {
+				if env[tag] {
+					return true
+				}
+				for i, t := range free {
+					if t == tag {
+						return set&(1<<i) != 0
+					}
+				}
+				return false
+			})
+			if !ok {
+				continue
+			}
+			var tags []string
+			for i, t := range free {
+				if set&(1<<i) != 0 {
+					tags = append(tags, t)
+				}
+			}
+			return tags, nil
+		}
+	}
+	return nil, fmt.Errorf("build constraint %q can't be satisfied for %s/%s", expr, build.Default.GOOS, build.Default.GOARCH)
+}
+
+// negatedConstraint returns the build constraint expression that guards the output
+// file, the negation of expr, so that the source is used when expr is satisfied
+// and the generated code otherwise.
+func negatedConstraint(expr constraint.Expr) string {
+	return (&constraint.NotExpr{X: expr}).String()
+}
+
+// environmentTags returns the build tags that are satisfied by the build environment
+// of ctxt, in the way the go command determines them.
+func environmentTags(ctxt build.Context) map[string]bool {
+	tags := map[string]bool{
+		ctxt.GOOS:   true,
+		ctxt.GOARCH: true,
+	}
+	if ctxt.CgoEnabled {
+		tags["cgo"] = true
+	}
+	if unixOS[ctxt.GOOS] {
+		tags["unix"] = true
+	}
+	switch ctxt.GOOS {
+	case "android":
+		tags["linux"] = true
+	case "illumos":
+		tags["solaris"] = true
+	case "ios":
+		tags["darwin"] = true
+	}
+	for _, t := range ctxt.ReleaseTags {
+		tags[t] = true
+	}
+	for _, t := range ctxt.ToolTags {
+		tags[t] = true
+	}
+	for _, t := range ctxt.BuildTags {
+		tags[t] = true
+	}
+	return tags
+}
+
+// unixOS is the set of GOOS values matched by the "unix" build tag.
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true,
+	"openbsd": true, "solaris": true,
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"go/build"
+	"reflect"
+	"testing"
+)
+
+func TestSatisfyingTags(t *testing.T) {
+	tests := []struct {
+		tag         string
+		want        []string
+		wantErr     bool
+		wantNegated string
+	}{
+		{tag: "dev", want: []string{"dev"}, wantNegated: "!dev"},
+		{tag: "dev && !wasm", want: []string{"dev"}, wantNegated: "!(dev && !wasm)"},
+		{tag: "assetsdev || debug", want: []string{"assetsdev"}, wantNegated: "!(assetsdev || debug)"},
+		{tag: "dev && " + build.Default.GOOS, want: []string{"dev"}, wantNegated: "!(dev && " + build.Default.GOOS + ")"},
+		{tag: "dev && !" + build.Default.GOOS, wantErr: true, wantNegated: "!(dev && !" + build.Default.GOOS + ")"},
+	}
+	for _, tc := range tests {
+		expr, err := parseTagFlag(tc.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := satisfyingTags(expr)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("satisfyingTags(%q): got error %v, want error %v", tc.tag, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("satisfyingTags(%q): got %q, want %q", tc.tag, got, tc.want)
+		}
+		if got := negatedConstraint(expr); got != tc.wantNegated {
+			t.Errorf("negatedConstraint(%q): got %q, want %q", tc.tag, got, tc.wantNegated)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build dev
+
+package assets
+
+import "net/http"
+
+// Assets contains the static files.
+var Assets = http.Dir("static")
@@ -0,0 +1,10 @@
+//go:build dev
+
+package assets
+
+import "net/http"
+
+// NewDocs returns the documentation.
+func NewDocs() (http.FileSystem, error) {
+	return http.Dir("docs"), nil
+}
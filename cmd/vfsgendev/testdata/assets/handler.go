@@ -0,0 +1,15 @@
+package assets
+
+import "net/http"
+
+// Handler serves the static files, with or without the dev build tag.
+var Handler = http.FileServer(Assets)
+
+// DocsHandler serves the documentation, with or without the dev build tag.
+func DocsHandler() (http.Handler, error) {
+	docs, err := NewDocs()
+	if err != nil {
+		return nil, err
+	}
+	return http.FileServer(docs), nil
+}
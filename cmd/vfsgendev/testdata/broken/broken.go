@@ -0,0 +1,5 @@
+package broken
+
+import "net/http"
+
+var Assets http.FileSystem = "assets"
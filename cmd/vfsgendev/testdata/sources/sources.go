@@ -0,0 +1,31 @@
+// Package sources declares sources of different kinds, for tests.
+package sources
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+)
+
+// A and B are declared together.
+var A, B = http.Dir("a"), 3
+
+var (
+	// C has its own comment.
+	C http.FileSystem = http.Dir("c")
+
+	D = os.DirFS("d")
+)
+
+// NewE returns a filesystem.
+func NewE() (http.FileSystem, error) { return http.Dir("e"), nil }
+
+func F() http.FileSystem { return http.Dir("f") }
+
+func G(dir string) http.FileSystem { return http.Dir(dir) }
+
+func H() http.Dir { return http.Dir("h") }
+
+func I() fs.FS { return os.DirFS("i") }
+
+const J = "j"
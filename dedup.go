@@ -0,0 +1,145 @@
+package vfsgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// minSharedSize is the minimum size of content that's shared by files. Referring to
+// a variable takes about as much generated code as smaller content does.
+const minSharedSize = 64
+
+// sharedContents tracks files with identical content, whose content is
+// written once to a variable that all their definitions refer to.
+type sharedContents struct {
+	variablePrefix string
+
+	count map[[sha256.Size]byte]int       // Content hash -> number of files with that content.
+	first map[[sha256.Size]byte]*FileInfo // Content hash -> first file written with that content.
+	hash  map[*node][sha256.Size]byte     // File -> content hash, for files that may share content.
+
+	// Content of each file after opt.Transform is applied, if there are transforms,
+	// so that they're applied once.
+	transformed map[*node][]byte
+
+	// Declarations of the variables that hold shared content,
+	// written after the variable of the generated filesystem.
+	decls bytes.Buffer
+}
+
+// newSharedContents finds files with identical content in the tree rooted at root,
+// after opt.Transform is applied. Without transforms, only files whose size is
+// the same as that of another file are read, since others can't be identical.
+func newSharedContents(root *node, opt Options) (*sharedContents, error) {
+	sc := &sharedContents{
+		variablePrefix: defaultTypePrefix + opt.VariableName + "۰sharedContent",
+		count:          make(map[[sha256.Size]byte]int),
+		first:          make(map[[sha256.Size]byte]*FileInfo),
+		hash:           make(map[*node][sha256.Size]byte),
+		transformed:    make(map[*node][]byte),
+	}
+	sizes := make(map[int64][]*node) // Size -> files of that size, without transforms.
+	err := sc.visit(root, opt, sizes)
+	if err != nil {
+		return nil, err
+	}
+	for size, files := range sizes {
+		if len(files) < 2 || size < minSharedSize {
+			continue
+		}
+		for _, n := range files {
+			err := sc.hashFile(n)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sc, nil
+}
+
+// visit applies opt.Transform to each file in the tree rooted at n and hashes
+// the result, or groups files by size in sizes if there are no transforms.
+func (sc *sharedContents) visit(n *node, opt Options, sizes map[int64][]*node) error {
+	if n.isDir() {
+		for _, e := range n.sortedEntries() {
+			err := sc.visit(e, opt, sizes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(opt.Transform) == 0 {
+		sizes[n.fi.Size()] = append(sizes[n.fi.Size()], n)
+		return nil
+	}
+	f, err := n.fs.Open(n.srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := transform(n.path, f, opt.Transform)
+	if err != nil {
+		return err
+	}
+	sc.transformed[n] = b
+	if len(b) >= minSharedSize {
+		sc.add(n, sha256.Sum256(b))
+	}
+	return nil
+}
+
+// hashFile reads and hashes the content of file n.
+func (sc *sharedContents) hashFile(n *node) error {
+	f, err := n.fs.Open(n.srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	sc.add(n, sum)
+	return nil
+}
+
+func (sc *sharedContents) add(n *node, sum [sha256.Size]byte) {
+	sc.hash[n] = sum
+	sc.count[sum]++
+}
+
+// lookup reports whether the content of file n is shared with other files.
+// If so, it returns the first file written with that content, or nil if n is
+// the first, in which case file.SharedContent is set to the name of a new variable.
+func (sc *sharedContents) lookup(n *node, file *FileInfo) (first *FileInfo, shared bool) {
+	sum, ok := sc.hash[n]
+	if !ok || sc.count[sum] < 2 {
+		return nil, false
+	}
+	if first := sc.first[sum]; first != nil {
+		return first, true
+	}
+	file.SharedContent = fmt.Sprintf("%s%d", sc.variablePrefix, len(sc.first))
+	sc.first[sum] = file
+	return nil, true
+}
+
+// check returns an error if the content written for file n, whose content
+// is shared, is not the content that was found to be shared. That happens
+// when the file is modified during generation.
+func (sc *sharedContents) check(n *node, file *FileInfo) error {
+	if file.SHA256 != sc.hash[n] {
+		return fmt.Errorf("%s: %v", n.path, errChanged)
+	}
+	return nil
+}
+
+var errChanged = errors.New("file changed during generation")
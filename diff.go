@@ -0,0 +1,164 @@
+package vfsgen
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+// ChangeKind is the kind of a change between two filesystems.
+type ChangeKind int
+
+const (
+	// Added means the path exists only in the second filesystem.
+	Added ChangeKind = iota + 1
+
+	// Removed means the path exists only in the first filesystem.
+	Removed
+
+	// ContentChanged means the file at the path has different content.
+	ContentChanged
+
+	// ModTimeChanged means the file or directory at the path has the same content,
+	// but a different modification time.
+	ModTimeChanged
+
+	// ModeChanged means the file or directory at the path has a different mode.
+	ModeChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case ContentChanged:
+		return "content changed"
+	case ModTimeChanged:
+		return "modtime changed"
+	case ModeChanged:
+		return "mode changed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a difference at a path between two filesystems.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	A, B os.FileInfo // File info in the first and second filesystem, nil if the path doesn't exist there.
+}
+
+// String returns a human-readable description of the change.
+func (c Change) String() string {
+	switch c.Kind {
+	case ContentChanged:
+		return fmt.Sprintf("%v %s (size %d -> %d)", c.Kind, c.Path, c.A.Size(), c.B.Size())
+	case ModTimeChanged:
+		return fmt.Sprintf("%v %s (%v -> %v)", c.Kind, c.Path, c.A.ModTime().UTC(), c.B.ModTime().UTC())
+	case ModeChanged:
+		return fmt.Sprintf("%v %s (%v -> %v)", c.Kind, c.Path, c.A.Mode(), c.B.Mode())
+	default:
+		return fmt.Sprintf("%v %s", c.Kind, c.Path)
+	}
+}
+
+// Diff walks filesystems a and b, and returns the changes from a to b sorted by path.
+// A path can have both a ContentChanged or ModTimeChanged change, and a ModeChanged change.
+// A path that is a file in one filesystem and a directory in the other is reported as
+// removed and added.
+//
+// Filesystems generated by vfsgen report fixed modes, so when comparing one to
+// its input, ModeChanged changes are expected and may need to be ignored.
+func Diff(a, b http.FileSystem) ([]Change, error) {
+	as, err := walkInfos(a)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := walkInfos(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for path := range as {
+		paths = append(paths, path)
+	}
+	for path := range bs {
+		if _, ok := as[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, path := range paths {
+		afi, bfi := as[path], bs[path]
+		switch {
+		case bfi == nil:
+			changes = append(changes, Change{Path: path, Kind: Removed, A: afi})
+			continue
+		case afi == nil:
+			changes = append(changes, Change{Path: path, Kind: Added, B: bfi})
+			continue
+		case afi.IsDir() != bfi.IsDir():
+			changes = append(changes,
+				Change{Path: path, Kind: Removed, A: afi},
+				Change{Path: path, Kind: Added, B: bfi})
+			continue
+		}
+
+		same := true
+		if !afi.IsDir() {
+			same, err = sameContent(a, b, path, afi, bfi)
+			if err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case !same:
+			changes = append(changes, Change{Path: path, Kind: ContentChanged, A: afi, B: bfi})
+		case !afi.ModTime().Equal(bfi.ModTime()):
+			changes = append(changes, Change{Path: path, Kind: ModTimeChanged, A: afi, B: bfi})
+		}
+		if afi.Mode() != bfi.Mode() {
+			changes = append(changes, Change{Path: path, Kind: ModeChanged, A: afi, B: bfi})
+		}
+	}
+	return changes, nil
+}
+
+// walkInfos walks fs and returns the file info of every path in it.
+func walkInfos(fs http.FileSystem) (map[string]os.FileInfo, error) {
+	infos := make(map[string]os.FileInfo)
+	err := vfsutil.Walk(fs, "/", func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		infos[path] = fi
+		return nil
+	})
+	return infos, err
+}
+
+// sameContent reports whether the file at path has the same content in a and b.
+func sameContent(a, b http.FileSystem, path string, afi, bfi os.FileInfo) (bool, error) {
+	if afi.Size() != bfi.Size() {
+		return false, nil
+	}
+	ab, err := vfsutil.ReadFile(a, path)
+	if err != nil {
+		return false, err
+	}
+	bb, err := vfsutil.ReadFile(b, path)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ab, bb), nil
+}
@@ -0,0 +1,78 @@
+package vfsgen_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+func TestDiff(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	mt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, f := range []struct {
+		dir, path, content string
+		modTime            time.Time
+		mode               os.FileMode
+	}{
+		{a, "same.txt", "Same.", mt, 0644},
+		{b, "same.txt", "Same.", mt, 0644},
+		{a, "removed.txt", "Removed.", mt, 0644},
+		{b, "added.txt", "Added.", mt, 0644},
+		{a, "content.txt", "Old.", mt, 0644},
+		{b, "content.txt", "New.", mt.Add(time.Hour), 0644},
+		{a, "modtime.txt", "Same.", mt, 0644},
+		{b, "modtime.txt", "Same.", mt.Add(time.Hour), 0644},
+		{a, "mode.txt", "Same.", mt, 0644},
+		{b, "mode.txt", "Same.", mt, 0444},
+		{a, "kind", "File.", mt, 0644},
+		{b, "kind/file.txt", "File.", mt, 0644},
+	} {
+		filename := filepath.Join(f.dir, filepath.FromSlash(f.path))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(f.content), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(filename, f.modTime, f.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []string{a, b, filepath.Join(b, "kind")} {
+		if err := os.Chtimes(dir, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes, err := vfsgen.Diff(http.Dir(a), http.Dir(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"added /added.txt",
+		"content changed /content.txt (size 4 -> 4)",
+		"removed /kind",
+		"added /kind",
+		"added /kind/file.txt",
+		"mode changed /mode.txt (-rw-r--r-- -> -r--r--r--)",
+		"modtime changed /modtime.txt (2020-01-02 03:04:05 +0000 UTC -> 2020-01-02 04:04:05 +0000 UTC)",
+		"removed /removed.txt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got changes:\n%q\nwant:\n%q", got, want)
+	}
+
+	changes, err = vfsgen.Diff(http.Dir(a), http.Dir(a))
+	if err != nil || len(changes) != 0 {
+		t.Errorf("got %v, %v, want no changes", changes, err)
+	}
+}
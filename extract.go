@@ -0,0 +1,114 @@
+package vfsgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractOptions for extracting a filesystem to disk.
+type ExtractOptions struct {
+	// KeepGzip makes files that provide direct access to their gzip compressed bytes
+	// (via a GzipBytes method, as compressed files in generated code do) to be written
+	// with those bytes as is, to a file with a ".gz" extension added to its name.
+	KeepGzip bool
+}
+
+// Extract writes out every file and directory of filesystem fs to directory dir
+// on disk, creating it if needed. Modification times and modes of files and directories
+// are restored, except that zero modification times are left unchanged.
+//
+// Files that already exist in dir are replaced, so a filesystem can be extracted
+// again into the same directory, even though its files may be read-only.
+//
+// Extracting a filesystem generated by vfsgen and generating code from the result
+// produces the same code as the original generation, given the same options,
+// except for directories with a zero modification time, such as those created by
+// MountPrefix or Mount.At, which get the time of extraction instead.
+func Extract(fs http.FileSystem, dir string, opt ExtractOptions) error {
+	return extract(fs, "/", dir, opt)
+}
+
+// extract writes out the file or directory at path in fs to dst on disk.
+func extract(fs http.FileSystem, path, dst string, opt ExtractOptions) error {
+	f, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		err = os.MkdirAll(dst, 0755)
+		if err != nil {
+			return err
+		}
+		// Make sure entries can be written, if the directory was extracted before.
+		err = os.Chmod(dst, 0755)
+		if err != nil {
+			return err
+		}
+		fis, err := f.Readdir(0)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			name := fi.Name()
+			if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+				return fmt.Errorf("%s: invalid name %q in directory", path, name)
+			}
+			err := extract(fs, pathpkg.Join(path, name), filepath.Join(dst, name), opt)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		var r io.Reader = f
+		if gb, ok := f.(interface{ GzipBytes() []byte }); ok && opt.KeepGzip {
+			dst += ".gz"
+			r = bytes.NewReader(gb.GzipBytes())
+		}
+		err = writeFile(dst, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Restore attributes last, since writing directory entries changes
+	// the modification time of the directory, and its mode may not allow it.
+	err = os.Chmod(dst, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if mt := fi.ModTime(); !mt.IsZero() {
+		err = os.Chtimes(dst, mt, mt)
+	}
+	return err
+}
+
+// writeFile writes the contents of r to a new file named filename,
+// replacing an existing file, which may be read-only.
+func writeFile(filename string, r io.Reader) error {
+	err := os.Remove(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,162 @@
+package vfsgen_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/vfsgen"
+	"golang.org/x/tools/godoc/vfs/httpfs"
+	"golang.org/x/tools/godoc/vfs/mapfs"
+)
+
+// Verify that extracting a generated filesystem and generating code
+// from the result produces the same code as the original generation.
+func TestExtract_roundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	for path, content := range map[string]string{
+		"not-compressable-file.txt":    "Not compressable.",
+		"folder/compressable-file.txt": "This text compresses easily. " + strings.Repeat(" Go!", 128),
+		"folder/empty/.keep":           "",
+	} {
+		filename := filepath.Join(src, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, path := range []string{"not-compressable-file.txt", "folder/compressable-file.txt", "folder/empty/.keep", "folder/empty", "folder", "."} {
+		if err := os.Chtimes(filepath.Join(src, filepath.FromSlash(path)), mt, mt); err != nil {
+			t.Fatal(err)
+		}
+		mt = mt.Add(time.Hour)
+	}
+
+	// Generate code from src, and extract it using a program built with the generated code.
+	prog := filepath.Join(tempDir, "prog")
+	if err := os.Mkdir(prog, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := vfsgen.Generate(http.Dir(src), vfsgen.Options{Filename: filepath.Join(prog, "assets_vfsdata.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(prog, "main.go"), []byte(`package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+func main() {
+	err := vfsgen.Extract(assets, os.Args[1], vfsgen.ExtractOptions{})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Extract twice, to verify that extracting again replaces the read-only files.
+	out := filepath.Join(tempDir, "out")
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command("go", "run", filepath.Join(prog, "main.go"), filepath.Join(prog, "assets_vfsdata.go"), out)
+		if b, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("extraction %d: err: %v\nout: %s", i+1, err, b)
+		}
+	}
+
+	fi, err := os.Stat(filepath.Join(out, "not-compressable-file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode(), os.FileMode(0444); got != want {
+		t.Errorf("got extracted file mode %v, want %v", got, want)
+	}
+
+	for _, dir := range []string{src, out} {
+		err := vfsgen.Generate(http.Dir(dir), vfsgen.Options{Filename: dir + ".go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(src + ".go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out + ".go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("code generated from extracted filesystem differs from original:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExtract_keepGzip(t *testing.T) {
+	compressable := "This text compresses easily. " + strings.Repeat(" Go!", 128)
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+		"not-compressable-file.txt":    "Not compressable.",
+		"folder/compressable-file.txt": compressable,
+	})), vfsgen.Options{Filename: filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := vfsgen.LoadGenerated(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keepGzip := range []bool{false, true} {
+		out := t.TempDir()
+		err := vfsgen.Extract(fs, out, vfsgen.ExtractOptions{KeepGzip: keepGzip})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Only compressed files are written as is, with a ".gz" extension.
+		name := filepath.Join(out, "folder", "compressable-file.txt")
+		if keepGzip {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("KeepGzip %v: got %s written: %v", keepGzip, name, err)
+			}
+			name += ".gz"
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keepGzip {
+			gr, err := gzip.NewReader(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("KeepGzip %v: %s: %v", keepGzip, name, err)
+			}
+			b, err = io.ReadAll(gr)
+			if err != nil {
+				t.Fatalf("KeepGzip %v: %s: %v", keepGzip, name, err)
+			}
+		}
+		if got := string(b); got != compressable {
+			t.Errorf("KeepGzip %v: got %s content %q, want %q", keepGzip, name, got, compressable)
+		}
+		b, err = os.ReadFile(filepath.Join(out, "not-compressable-file.txt"))
+		if got, want := string(b), "Not compressable."; err != nil || got != want {
+			t.Errorf("KeepGzip %v: got not-compressable-file.txt content %q, %v, want %q", keepGzip, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package vfsgen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"strings"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+// ignoreFilename is the name of the optional ignore file read from the root
+// of the input filesystem when Options.IgnoreFile is set.
+const ignoreFilename = ".vfsgenignore"
+
+// filter decides which paths of the input filesystem are included in the generated code.
+type filter struct {
+	include []string
+	exclude []string
+	ignore  []ignoreRule // Rules from the ignore file, in order of appearance.
+}
+
+// newFilter creates a filter for input filesystem fs from the options in opt.
+// It reads the ignore file from fs if opt.IgnoreFile is set.
+func newFilter(fs http.FileSystem, opt Options) (*filter, error) {
+	for _, patterns := range [][]string{opt.Include, opt.Exclude} {
+		for _, p := range patterns {
+			if err := validatePattern(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	f := &filter{
+		include: opt.Include,
+		exclude: opt.Exclude,
+	}
+	if !opt.IgnoreFile {
+		return f, nil
+	}
+	b, err := vfsutil.ReadFile(fs, "/"+ignoreFilename)
+	if os.IsNotExist(err) {
+		return f, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f.ignore, err = parseIgnoreFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ignoreFilename, err)
+	}
+	// The ignore file configures generation, it's not a part of the output.
+	f.exclude = append(f.exclude[:len(f.exclude):len(f.exclude)], "/"+ignoreFilename)
+	return f, nil
+}
+
+// keep reports whether the file or directory at path should be included in the generated code.
+// Excluded directories are omitted together with all their contents.
+// Include patterns only apply to files; directories are kept unless excluded,
+// and ones left without included files are removed by findAllFiles.
+func (f *filter) keep(path string, isDir bool) bool {
+	if f == nil || path == "/" {
+		return true
+	}
+	rel := strings.TrimPrefix(path, "/")
+	for _, p := range f.exclude {
+		if matchPattern(p, rel) {
+			return false
+		}
+	}
+	if f.ignored(rel, isDir) {
+		return false
+	}
+	if isDir || len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if matchPattern(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether rel is ignored by the ignore file rules.
+// As with gitignore, the last matching rule wins.
+func (f *filter) ignored(rel string, isDir bool) bool {
+	var ignored bool
+	for _, r := range f.ignore {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.match(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// ignoreRule is a single pattern line of an ignore file.
+type ignoreRule struct {
+	pattern  string
+	negate   bool // Pattern was prefixed with "!".
+	dirOnly  bool // Pattern had a trailing "/".
+	anchored bool // Pattern contained a "/", so it's relative to the root.
+}
+
+func (r ignoreRule) match(rel string) bool {
+	if !r.anchored {
+		ok, _ := pathpkg.Match(r.pattern, pathpkg.Base(rel))
+		return ok
+	}
+	return matchGlob(r.pattern, rel)
+}
+
+// parseIgnoreFile parses the contents of an ignore file that uses gitignore syntax.
+func parseIgnoreFile(b []byte) ([]ignoreRule, error) {
+	var rules []ignoreRule
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		switch {
+		case strings.HasPrefix(line, "!"):
+			r.negate = true
+			line = line[1:]
+		case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		r.anchored = strings.Contains(line, "/")
+		r.pattern = strings.TrimPrefix(line, "/")
+		if err := validatePattern(r.pattern); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, sc.Err()
+}
+
+// matchPattern reports whether rel, a slash-separated path relative to the root,
+// matches an include or exclude pattern. A pattern without a slash matches
+// the base name at any depth, otherwise it's matched against the whole path
+// (a leading slash is optional).
+func matchPattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := pathpkg.Match(pattern, pathpkg.Base(rel))
+		return ok
+	}
+	return matchGlob(strings.TrimPrefix(pattern, "/"), rel)
+}
+
+// matchGlob reports whether the slash-separated rel matches pattern.
+// Pattern elements use path.Match syntax, and additionally a "**" element
+// matches zero or more path elements.
+func matchGlob(pattern, rel string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchElems(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := pathpkg.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// validatePattern returns an error if pattern is malformed.
+func validatePattern(pattern string) error {
+	if _, err := pathpkg.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package vfsgen
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.map", "app.js.map", true},
+		{"*.map", "static/js/app.js.map", true},
+		{"*.map", "static/js/app.js", false},
+		{".DS_Store", "a/b/.DS_Store", true},
+		{"static/*.css", "static/app.css", true},
+		{"static/*.css", "static/css/app.css", false},
+		{"/static/*.css", "static/app.css", true},
+		{"static/**/*.css", "static/app.css", true},
+		{"static/**/*.css", "static/a/b/app.css", true},
+		{"static/**/*.css", "other/app.css", false},
+		{"**/*.css", "app.css", true},
+		{"**/*.css", "a/b/app.css", true},
+		{"static/**", "static/a/b", true},
+		{"static/**", "other/a", false},
+	}
+	for _, tc := range tests {
+		if got := matchPattern(tc.pattern, tc.rel); got != tc.want {
+			t.Errorf("matchPattern(%q, %q): got %v, want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestFilterIgnoreFile(t *testing.T) {
+	rules, err := parseIgnoreFile([]byte(`# Comment.
+*~
+node_modules/
+/build
+docs/*.md
+!docs/README.md
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &filter{ignore: rules}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/index.html", false, true},
+		{"/index.html~", false, false},
+		{"/a/b/index.html~", false, false},
+		{"/node_modules", true, false},
+		{"/a/node_modules", true, false},
+		{"/node_modules", false, true}, // Not a directory.
+		{"/build", true, false},
+		{"/a/build", true, true}, // Anchored to root.
+		{"/docs/guide.md", false, false},
+		{"/docs/README.md", false, true},
+		{"/docs/a/guide.md", false, true},
+	}
+	for _, tc := range tests {
+		if got := f.keep(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("keep(%q, %v): got %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
@@ -3,173 +3,322 @@ package vfsgen
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"go/token"
 	"io"
 	"net/http"
 	"os"
 	pathpkg "path"
-	"sort"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
-
-	"github.com/shurcooL/httpfs/vfsutil"
 )
 
 // Generate Go code that statically implements input filesystem,
 // write the output to a file specified in opt.
 func Generate(input http.FileSystem, opt Options) error {
+	return GenerateMounts([]Mount{{At: "/", FS: input}}, opt)
+}
+
+// GenerateMounts generates Go code that statically implements a single filesystem
+// made by combining the given mounts, and writes the output to a file specified in opt.
+// Mounts are processed in order, and paths provided by more than one mount
+// are resolved according to opt.Conflict.
+func GenerateMounts(mounts []Mount, opt Options) error {
+	_, err := generateMounts(mounts, opt)
+	return err
+}
+
+// generateMounts is GenerateMounts, and also returns the table of contents
+// of the generated filesystem.
+func generateMounts(mounts []Mount, opt Options) (TOC, error) {
 	opt.fillMissing()
 
+	var toc TOC
+	err := opt.Budget.validate()
+	if err != nil {
+		return toc, err
+	}
+
+	t, err := newTemplate(opt)
+	if err != nil {
+		return toc, err
+	}
+
 	// Use an in-memory buffer to generate the entire output.
 	buf := new(bytes.Buffer)
 
-	err := t.ExecuteTemplate(buf, "Header", opt)
+	err = t.ExecuteTemplate(buf, "Header", opt)
 	if err != nil {
-		return err
+		return toc, err
 	}
 
-	var toc toc
-	err = findAndWriteFiles(buf, input, &toc)
+	decls := new(bytes.Buffer)
+	err = findAndWriteFiles(buf, decls, t, mounts, opt, &toc)
 	if err != nil {
-		return err
+		return toc, err
 	}
 
-	err = t.ExecuteTemplate(buf, "DirEntries", toc.dirs)
+	err = opt.Budget.check(toc)
 	if err != nil {
-		return err
+		return toc, err
 	}
 
+	err = t.ExecuteTemplate(buf, "DirEntries", toc.Dirs)
+	if err != nil {
+		return toc, err
+	}
+	buf.Write(decls.Bytes())
+
 	err = t.ExecuteTemplate(buf, "Trailer", toc)
 	if err != nil {
-		return err
+		return toc, err
+	}
+
+	if opt.PathConstants {
+		pc, err := newPathConstants(opt, toc)
+		if err != nil {
+			return toc, err
+		}
+		err = t.ExecuteTemplate(buf, "PathConstants", pc)
+		if err != nil {
+			return toc, err
+		}
+	}
+
+	err = t.ExecuteTemplate(buf, "Footer", toc)
+	if err != nil {
+		return toc, err
 	}
 
 	// Write output file (all at once).
 	err = os.WriteFile(opt.Filename, buf.Bytes(), 0644)
-	return err
+	if err != nil {
+		return toc, err
+	}
+
+	if opt.ManifestFilename != "" {
+		err = writeManifest(opt.ManifestFilename, toc)
+	}
+	return toc, err
 }
 
-type toc struct {
-	dirs []*dirInfo
+// TOC is the table of contents of the generated filesystem.
+// It's the data of the "Trailer" and "Footer" templates.
+type TOC struct {
+	Dirs  []*DirInfo  // All directories, in the order they were written.
+	Files []*FileInfo // All files, in the order they were written.
 
 	HasCompressedFile bool // There's at least one compressedFile.
 	HasFile           bool // There's at least one uncompressed file.
+	HasDirEntries     bool // There's at least one directory with entries.
 }
 
-// fileInfo is a definition of a file.
-type fileInfo struct {
+// FileInfo is a definition of a file.
+// It's the data of the "CompressedFileInfo-Before", "CompressedFileInfo-After",
+// "FileInfo-Before" and "FileInfo-After" templates.
+type FileInfo struct {
 	Path             string
 	Name             string
 	ModTime          time.Time
 	UncompressedSize int64
+
+	// The following are set once the content of the file is written,
+	// so they're not yet available to the "-Before" templates.
+	StoredSize int64             // Size of the content in the generated code.
+	Compressed bool              // The content is gzip compressed.
+	SHA256     [sha256.Size]byte // SHA-256 hash of the uncompressed content.
+
+	// SharedContent is the name of the variable that holds the content, if the
+	// file has the same content as other files. It's set before the content is
+	// written, and the content is written once, for the first of these files.
+	SharedContent string
 }
 
-// dirInfo is a definition of a directory.
-type dirInfo struct {
+// DirInfo is a definition of a directory.
+// It's the data of the "DirInfo" template, and DirInfo values
+// are the data of the "DirEntries" template.
+type DirInfo struct {
 	Path    string
 	Name    string
 	ModTime time.Time
 	Entries []string
 }
 
-// findAndWriteFiles recursively finds all the file paths in the given directory tree.
-// They are added to the given map as keys. Values will be safe function names
-// for each file, which will be used when generating the output code.
-func findAndWriteFiles(buf *bytes.Buffer, fs http.FileSystem, toc *toc) error {
-	walkFn := func(path string, fi os.FileInfo, r io.ReadSeeker, err error) error {
-		if err != nil {
-			// Consider all errors reading the input filesystem as fatal.
-			return err
-		}
+// findAndWriteFiles recursively finds all the files and directories in the given
+// mounts that are kept by their filters, and writes their definitions to buf.
+// Declarations of variables that hold content shared by files with identical
+// content are written to decls.
+func findAndWriteFiles(buf, decls *bytes.Buffer, t *template.Template, mounts []Mount, opt Options, toc *TOC) error {
+	tr, err := findAllFiles(mounts, opt)
+	if err != nil {
+		return err
+	}
+	sc, err := newSharedContents(tr.root, opt)
+	if err != nil {
+		return err
+	}
+	err = writeNode(buf, t, opt, tr.root, toc, sc)
+	if err != nil {
+		return err
+	}
+	_, err = sc.decls.WriteTo(decls)
+	return err
+}
 
-		switch fi.IsDir() {
-		case false:
-			file := &fileInfo{
-				Path:             path,
-				Name:             pathpkg.Base(path),
-				ModTime:          fi.ModTime().UTC(),
-				UncompressedSize: fi.Size(),
-			}
+// writeNode writes the definition of n, followed by definitions
+// of all its directory entries in lexical order.
+func writeNode(buf *bytes.Buffer, t *template.Template, opt Options, n *node, toc *TOC, sc *sharedContents) error {
+	var modTime time.Time
+	if n.fi != nil && opt.ModTime != ModTimeZero {
+		modTime = n.fi.ModTime().UTC()
+	}
+
+	if !n.isDir() {
+		file := &FileInfo{
+			Path:             n.path,
+			Name:             pathpkg.Base(n.path),
+			ModTime:          modTime,
+			UncompressedSize: n.fi.Size(),
+		}
 
-			marker := buf.Len()
+		first, shared := sc.lookup(n, file)
+		if first != nil {
+			// The content was written for an earlier file, refer to it.
+			file.UncompressedSize, file.SharedContent = first.UncompressedSize, first.SharedContent
+			file.StoredSize, file.Compressed, file.SHA256 = first.StoredSize, first.Compressed, first.SHA256
+			return writeSharedFileInfo(buf, t, file, toc)
+		}
 
-			// Write CompressedFileInfo.
-			err = writeCompressedFileInfo(buf, file, r)
-			switch err {
-			default:
+		var r io.ReadSeeker
+		if b, ok := sc.transformed[n]; ok {
+			r = bytes.NewReader(b)
+			file.UncompressedSize = int64(len(b))
+		} else {
+			f, err := n.fs.Open(n.srcPath)
+			if err != nil {
 				return err
-			case nil:
-				toc.HasCompressedFile = true
-			// If compressed file is not smaller than original, revert and write original file.
-			case errCompressedNotSmaller:
+			}
+			defer f.Close()
+			r = f
+		}
+
+		// Write the content to a variable declaration instead of the definition of the file,
+		// if it's shared with other files.
+		w, compressedName, name := buf, "CompressedFileInfo", "FileInfo"
+		if shared {
+			w, compressedName, name = &sc.decls, "SharedContent", "SharedContent"
+		}
+		marker := w.Len()
+
+		// Write CompressedFileInfo, hashing the content as it's read in full.
+		h := sha256.New()
+		var err error
+		switch {
+		case opt.NoCompression:
+			err = hashNotCompressed(r, h)
+		case opt.CacheDir != "":
+			// The cache key includes the hash of the content, so it's read before compressing.
+			_, err = io.Copy(h, r)
+			if err == nil {
 				_, err = r.Seek(0, io.SeekStart)
-				if err != nil {
-					return err
-				}
-
-				buf.Truncate(marker)
-
-				// Write FileInfo.
-				err = writeFileInfo(buf, file, r)
-				if err != nil {
-					return err
-				}
-				toc.HasFile = true
 			}
-		case true:
-			entries, err := readDirPaths(fs, path)
-			if err != nil {
+			if err == nil {
+				var sum [sha256.Size]byte
+				h.Sum(sum[:0])
+				err = writeCachedFileInfo(w, t, compressedName, file, r, sum, opt.CacheDir, opt.CompressionLevel)
+			}
+		default:
+			err = writeCompressedFileInfo(w, t, compressedName, file, io.TeeReader(r, h), opt.CompressionLevel, nil)
+		}
+		h.Sum(file.SHA256[:0])
+		if shared && (err == nil || err == errCompressedNotSmaller) {
+			if err := sc.check(n, file); err != nil {
 				return err
 			}
-
-			dir := &dirInfo{
-				Path:    path,
-				Name:    pathpkg.Base(path),
-				ModTime: fi.ModTime().UTC(),
-				Entries: entries,
+		}
+		switch err {
+		default:
+			return err
+		case nil:
+		// If compressed file is not smaller than original, revert and write original file.
+		case errCompressedNotSmaller:
+			_, err = r.Seek(0, io.SeekStart)
+			if err != nil {
+				return err
 			}
 
-			toc.dirs = append(toc.dirs, dir)
+			w.Truncate(marker)
 
-			// Write DirInfo.
-			err = t.ExecuteTemplate(buf, "DirInfo", dir)
+			// Write FileInfo.
+			err = writeFileInfo(w, t, name, file, r)
 			if err != nil {
 				return err
 			}
 		}
-
+		if shared {
+			return writeSharedFileInfo(buf, t, file, toc)
+		}
+		if file.Compressed {
+			toc.HasCompressedFile = true
+		} else {
+			toc.HasFile = true
+		}
+		toc.Files = append(toc.Files, file)
 		return nil
 	}
 
-	err := vfsutil.WalkFiles(fs, "/", walkFn)
-	return err
-}
+	entries := n.sortedEntries()
+	dir := &DirInfo{
+		Path:    n.path,
+		Name:    pathpkg.Base(n.path),
+		ModTime: modTime,
+	}
+	for _, e := range entries {
+		dir.Entries = append(dir.Entries, e.path)
+	}
+	if len(entries) > 0 {
+		toc.HasDirEntries = true
+	}
 
-// readDirPaths reads the directory named by dirname and returns
-// a sorted list of directory paths.
-func readDirPaths(fs http.FileSystem, dirname string) ([]string, error) {
-	fis, err := vfsutil.ReadDir(fs, dirname)
+	toc.Dirs = append(toc.Dirs, dir)
+
+	// Write DirInfo.
+	err := t.ExecuteTemplate(buf, "DirInfo", dir)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	paths := make([]string, len(fis))
-	for i := range fis {
-		paths[i] = pathpkg.Join(dirname, fis[i].Name())
+
+	for _, e := range entries {
+		err := writeNode(buf, t, opt, e, toc, sc)
+		if err != nil {
+			return err
+		}
 	}
-	sort.Strings(paths)
-	return paths, nil
+	return nil
 }
 
-// writeCompressedFileInfo writes CompressedFileInfo.
+// writeCompressedFileInfo writes CompressedFileInfo, or the compressed content
+// with other templates named name+"-Before" and name+"-After". The compressed
+// content is also written to cache, if it's not nil.
 // It returns errCompressedNotSmaller if compressed file is not smaller than original.
-func writeCompressedFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
-	err := t.ExecuteTemplate(w, "CompressedFileInfo-Before", file)
+func writeCompressedFileInfo(w io.Writer, t *template.Template, name string, file *FileInfo, r io.Reader, level int, cache io.Writer) error {
+	err := t.ExecuteTemplate(w, name+"-Before", file)
 	if err != nil {
 		return err
 	}
 	sw := &stringWriter{Writer: w}
-	gw, _ := gzip.NewWriterLevel(sw, gzip.BestCompression)
+	var gzw io.Writer = sw
+	if cache != nil {
+		gzw = io.MultiWriter(sw, cache)
+	}
+	gw, err := gzip.NewWriterLevel(gzw, level)
+	if err != nil {
+		return err
+	}
 	_, err = io.Copy(gw, r)
 	if err != nil {
 		return err
@@ -181,15 +330,26 @@ func writeCompressedFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
 	if sw.N >= file.UncompressedSize {
 		return errCompressedNotSmaller
 	}
-	err = t.ExecuteTemplate(w, "CompressedFileInfo-After", file)
+	file.StoredSize, file.Compressed = sw.N, true
+	err = t.ExecuteTemplate(w, name+"-After", file)
 	return err
 }
 
 var errCompressedNotSmaller = errors.New("compressed file is not smaller than original")
 
-// Write FileInfo.
-func writeFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
-	err := t.ExecuteTemplate(w, "FileInfo-Before", file)
+// hashNotCompressed reads r in full into h, and returns errCompressedNotSmaller
+// so that the file is written uncompressed.
+func hashNotCompressed(r io.Reader, h io.Writer) error {
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	return errCompressedNotSmaller
+}
+
+// Write FileInfo, or the content with other templates named name+"-Before" and name+"-After".
+func writeFileInfo(w io.Writer, t *template.Template, name string, file *FileInfo, r io.Reader) error {
+	err := t.ExecuteTemplate(w, name+"-Before", file)
 	if err != nil {
 		return err
 	}
@@ -198,11 +358,26 @@ func writeFileInfo(w io.Writer, file *fileInfo, r io.Reader) error {
 	if err != nil {
 		return err
 	}
-	err = t.ExecuteTemplate(w, "FileInfo-After", file)
+	file.StoredSize, file.Compressed = sw.N, false
+	err = t.ExecuteTemplate(w, name+"-After", file)
 	return err
 }
 
-var t = template.Must(template.New("").Funcs(template.FuncMap{
+// writeSharedFileInfo writes CompressedFileInfo or FileInfo of a file
+// whose content is in the variable named file.SharedContent.
+func writeSharedFileInfo(w io.Writer, t *template.Template, file *FileInfo, toc *TOC) error {
+	name := "FileInfo-Shared"
+	if file.Compressed {
+		name = "CompressedFileInfo-Shared"
+		toc.HasCompressedFile = true
+	} else {
+		toc.HasFile = true
+	}
+	toc.Files = append(toc.Files, file)
+	return t.ExecuteTemplate(w, name, file)
+}
+
+var templateFuncs = template.FuncMap{
 	"quote": strconv.Quote,
 	"comment": func(s string) (string, error) {
 		var buf bytes.Buffer
@@ -214,7 +389,46 @@ var t = template.Must(template.New("").Funcs(template.FuncMap{
 		err = cw.Close()
 		return buf.String(), err
 	},
-}).Parse(`{{define "Header"}}// Code generated by vfsgen; DO NOT EDIT.
+}
+
+// newTemplate returns the template for generating code with options opt.
+func newTemplate(opt Options) (*template.Template, error) {
+	replacePrefix := func(text string) string { return text }
+	if opt.TypePrefix != defaultTypePrefix && !opt.RuntimeImport {
+		if !token.IsIdentifier(opt.TypePrefix + "FS") {
+			return nil, fmt.Errorf("type prefix %q does not form valid identifiers", opt.TypePrefix)
+		}
+		replacePrefix = func(text string) string {
+			return strings.ReplaceAll(text, defaultTypePrefix, opt.TypePrefix)
+		}
+	}
+
+	t, err := template.New("").Funcs(templateFuncs).Parse(replacePrefix(templateText))
+	if err != nil {
+		return nil, err
+	}
+	if opt.RuntimeImport {
+		t, err = t.Parse(runtimeTemplateText)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opt.Templates != "" {
+		t, err = t.Parse(replacePrefix(opt.Templates))
+		if err != nil {
+			return nil, fmt.Errorf("parsing Options.Templates: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// defaultTypePrefix is the prefix of the names of types declared in the generated code.
+// It's written as is in templateText and Options.Templates, and is replaced
+// when Options.TypePrefix differs.
+const defaultTypePrefix = "vfsgen۰"
+
+// templateText is the text of the template that produces the generated code.
+const templateText = `{{define "Banner"}}{{end}}{{define "Header"}}{{template "Banner" .}}// Code generated by vfsgen; DO NOT EDIT.
 
 {{with .BuildTags}}//go:build {{.}}
 
@@ -258,6 +472,31 @@ var {{.VariableName}} = func() http.FileSystem {
 
 
 
+{{define "CompressedFileInfo-Shared"}}		{{quote .Path}}: &vfsgen۰CompressedFileInfo{
+			name:              {{quote .Name}},
+			modTime:           {{template "Time" .ModTime}},
+			uncompressedSize:  {{.UncompressedSize}},
+			compressedContent: {{.SharedContent}},
+		},
+{{end}}
+
+
+
+{{define "FileInfo-Shared"}}		{{quote .Path}}: &vfsgen۰FileInfo{
+			name:    {{quote .Name}},
+			modTime: {{template "Time" .ModTime}},
+			content: {{.SharedContent}},
+		},
+{{end}}
+
+
+
+{{define "SharedContent-Before"}}
+var {{.SharedContent}} = []byte("{{end}}{{define "SharedContent-After"}}")
+{{end}}
+
+
+
 {{define "DirInfo"}}		{{quote .Path}}: &vfsgen۰DirInfo{
 			name:    {{quote .Name}},
 			modTime: {{template "Time" .ModTime}},
@@ -477,4 +716,130 @@ func (d *vfsgen۰Dir) Readdir(count int) ([]os.FileInfo, error) {
 	time.Date({{.Year}}, {{printf "%d" .Month}}, {{.Day}}, {{.Hour}}, {{.Minute}}, {{.Second}}, {{.Nanosecond}}, time.UTC)
 {{- end -}}
 {{end}}
-`))
+
+
+
+{{define "PathConstants"}}
+// {{.Prefix}}Path is the path of a file or directory in {{.VariableName}}.
+type {{.Prefix}}Path string
+{{if .Paths}}
+// Paths of all files and directories in {{.VariableName}}.
+const ({{range .Paths}}
+	{{.Name}}{{.Padding}} {{$.Prefix}}Path = {{quote .Path}}{{end}}
+)
+{{end}}
+// {{.Prefix}}Open opens the file or directory at path p in {{.VariableName}}.
+func {{.Prefix}}Open(p {{.Prefix}}Path) (http.File, error) {
+	return {{.VariableName}}.Open(string(p))
+}
+
+// {{.Prefix}}MustRead returns the contents of the file at path p in {{.VariableName}}.
+// It panics if the file can't be read.
+func {{.Prefix}}MustRead(p {{.Prefix}}Path) []byte {
+	f, err := {{.VariableName}}.Open(string(p))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+{{end}}
+
+
+
+{{define "Footer"}}{{end}}
+`
+
+// runtimeTemplateText redefines the parts of templateText that differ
+// when the generated code imports the vfsgenrt package instead of
+// declaring its own types.
+const runtimeTemplateText = `{{define "Header"}}{{template "Banner" .}}// Code generated by vfsgen; DO NOT EDIT.
+
+{{with .BuildTags}}//go:build {{.}}
+
+{{end}}package {{.PackageName}}
+
+import (
+{{- if .PathConstants}}
+	"io"{{end}}
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shurcooL/vfsgen/vfsgenrt"
+)
+
+{{comment .VariableComment}}
+var {{.VariableName}} = func() http.FileSystem {
+	fs := vfsgenrt.FS{
+{{end}}
+
+
+
+{{define "CompressedFileInfo-Before"}}		{{quote .Path}}: &vfsgenrt.CompressedFileInfo{
+			BaseName:         {{quote .Name}},
+			ModifiedTime:     {{template "Time" .ModTime}},
+			UncompressedSize: {{.UncompressedSize}},
+{{/* This blank line separating CompressedContent is neccessary to prevent potential gofmt issues. See issue #19. */}}
+			CompressedContent: []byte("{{end}}{{define "CompressedFileInfo-After"}}"),
+		},
+{{end}}
+
+
+
+{{define "FileInfo-Before"}}		{{quote .Path}}: &vfsgenrt.FileInfo{
+			BaseName:     {{quote .Name}},
+			ModifiedTime: {{template "Time" .ModTime}},
+			Content:      []byte("{{end}}{{define "FileInfo-After"}}"),
+		},
+{{end}}
+
+
+
+{{define "CompressedFileInfo-Shared"}}		{{quote .Path}}: &vfsgenrt.CompressedFileInfo{
+			BaseName:          {{quote .Name}},
+			ModifiedTime:      {{template "Time" .ModTime}},
+			UncompressedSize:  {{.UncompressedSize}},
+			CompressedContent: {{.SharedContent}},
+		},
+{{end}}
+
+
+
+{{define "FileInfo-Shared"}}		{{quote .Path}}: &vfsgenrt.FileInfo{
+			BaseName:     {{quote .Name}},
+			ModifiedTime: {{template "Time" .ModTime}},
+			Content:      {{.SharedContent}},
+		},
+{{end}}
+
+
+
+{{define "DirInfo"}}		{{quote .Path}}: &vfsgenrt.DirInfo{
+			BaseName:     {{quote .Name}},
+			ModifiedTime: {{template "Time" .ModTime}},
+		},
+{{end}}
+
+
+
+{{define "DirEntries"}}	}
+{{range .}}{{if .Entries}}	fs[{{quote .Path}}].(*vfsgenrt.DirInfo).Entries = []os.FileInfo{{"{"}}{{range .Entries}}
+		fs[{{quote .}}].(os.FileInfo),{{end}}
+	}
+{{end}}{{end}}
+	return fs
+}()
+{{end}}
+
+
+
+{{define "Trailer"}}{{if not .HasDirEntries}}
+// We already imported "os" but ended up not using it. Avoid unused import error.
+var _ os.FileInfo
+{{end}}{{end}}
+`
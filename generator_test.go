@@ -1,15 +1,23 @@
 package vfsgen_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/shurcooL/httpfs/union"
+	"github.com/shurcooL/httpfs/vfsutil"
 	"github.com/shurcooL/vfsgen"
 	"golang.org/x/tools/godoc/vfs/httpfs"
 	"golang.org/x/tools/godoc/vfs/mapfs"
@@ -31,8 +39,12 @@ func Example() {
 	}
 }
 
+// notCompressable is content that's large enough to be shared by files,
+// but doesn't get smaller when compressed.
+const notCompressable = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ+/"
+
 // Verify that all possible combinations of {non-compressed,compressed} files build
-// successfully, and have no gofmt issues.
+// successfully, and have no gofmt issues, both with and without RuntimeImport.
 func TestGenerate_buildAndGofmt(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -75,30 +87,781 @@ func TestGenerate_buildAndGofmt(t *testing.T) {
 				"compressable-file.txt":     "This text compresses easily. " + strings.Repeat(" Go!", 128),
 			})),
 		},
+		{
+			// Both non-compressed and compressed files with shared content.
+			filename: "shared.go",
+			fs: httpfs.New(mapfs.New(map[string]string{
+				"a/not-compressable-file.txt": notCompressable,
+				"b/not-compressable-file.txt": notCompressable,
+				"a/compressable-file.txt":     "This text compresses easily. " + strings.Repeat(" Go!", 128),
+				"b/compressable-file.txt":     "This text compresses easily. " + strings.Repeat(" Go!", 128),
+			})),
+		},
 	}
 
-	for _, test := range tests {
-		filename := filepath.Join(tempDir, test.filename)
+	for _, runtimeImport := range []bool{false, true} {
+		for _, test := range tests {
+			filename := filepath.Join(tempDir, test.filename)
+			if runtimeImport {
+				filename = filepath.Join(tempDir, "runtime_"+test.filename)
+			}
 
-		err := vfsgen.Generate(test.fs, vfsgen.Options{
-			Filename:    filename,
-			PackageName: "test",
-		})
-		switch {
-		case test.wantError == nil && err != nil:
-			t.Fatalf("%s: vfsgen.Generate returned non-nil error: %v", test.filename, err)
-		case test.wantError != nil && !test.wantError(err):
-			t.Fatalf("%s: vfsgen.Generate returned wrong error: %v", test.filename, err)
+			err := vfsgen.Generate(test.fs, vfsgen.Options{
+				Filename:      filename,
+				PackageName:   "test",
+				RuntimeImport: runtimeImport,
+			})
+			switch {
+			case test.wantError == nil && err != nil:
+				t.Fatalf("%s: vfsgen.Generate returned non-nil error: %v", filename, err)
+			case test.wantError != nil && !test.wantError(err):
+				t.Fatalf("%s: vfsgen.Generate returned wrong error: %v", filename, err)
+			}
+			if test.wantError != nil {
+				continue
+			}
+
+			if out, err := exec.Command("go", "build", filename).CombinedOutput(); err != nil {
+				t.Errorf("err: %v\nout: %s", err, out)
+			}
+			if out, err := exec.Command("gofmt", "-d", "-s", filename).Output(); err != nil || len(out) != 0 {
+				t.Errorf("gofmt issue\nerr: %v\nout: %s", err, out)
+			}
+		}
+	}
+}
+
+func TestGenerate_filter(t *testing.T) {
+	fs := httpfs.New(mapfs.New(map[string]string{
+		".vfsgenignore":          "*~\n",
+		".DS_Store":              "",
+		"index.html":             "<html></html>",
+		"index.html~":            "backup",
+		"js/app.js":              "app();",
+		"js/app.js.map":          "{}",
+		"vendor/lib/lib.js":      "lib();",
+		"vendor/lib/lib.js.map":  "{}",
+		"vendor/lib/LICENSE.txt": "License.",
+		"css/app.css":            "body {}",
+		"img/icons/logo.png":     "",
+	}))
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	err := vfsgen.Generate(fs, vfsgen.Options{
+		Filename:   filename,
+		Include:    []string{"*.html", "*.js", "vendor/**/LICENSE.txt"},
+		Exclude:    []string{"*.map", ".DS_Store"},
+		IgnoreFile: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, path := range []string{"/index.html", "/js/app.js", "/vendor/lib/lib.js", "/vendor/lib/LICENSE.txt"} {
+		if !strings.Contains(out, strconv.Quote(path)+":") {
+			t.Errorf("generated code doesn't contain %q, but it should", path)
+		}
+	}
+	// Directories without included files are left out.
+	for _, path := range []string{"/.vfsgenignore", "/.DS_Store", "/index.html~", "/js/app.js.map", "/vendor/lib/lib.js.map", "/css", "/img", "/img/icons"} {
+		if strings.Contains(out, strconv.Quote(path)) {
+			t.Errorf("generated code contains %q, but it shouldn't", path)
+		}
+	}
+}
+
+func TestGenerate_stripAndMountPrefix(t *testing.T) {
+	fs := httpfs.New(mapfs.New(map[string]string{
+		"README.md":           "Readme.",
+		"web/src/app.ts":      "app();",
+		"web/dist/index.html": "<html></html>",
+		"web/dist/js/app.js":  "app();",
+	}))
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	err := vfsgen.Generate(fs, vfsgen.Options{
+		Filename:    filename,
+		StripPrefix: "/web/dist",
+		MountPrefix: "/docs/v1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		`"/": &vfsgen۰DirInfo{`,
+		`"/docs": &vfsgen۰DirInfo{`,
+		`"/docs/v1": &vfsgen۰DirInfo{`,
+		`"/docs/v1/index.html": &vfsgen۰FileInfo{`,
+		`"/docs/v1/js/app.js": &vfsgen۰FileInfo{`,
+		`fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/docs"].(os.FileInfo),
+	}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code doesn't contain %q, but it should", want)
+		}
+	}
+	for _, path := range []string{"/README.md", "/web", "/index.html", "/docs/v1/src"} {
+		if strings.Contains(out, strconv.Quote(path)) {
+			t.Errorf("generated code contains %q, but it shouldn't", path)
 		}
-		if test.wantError != nil {
+	}
+}
+
+func TestGenerateMounts(t *testing.T) {
+	build := httpfs.New(mapfs.New(map[string]string{
+		"index.html":   "A",
+		"version.json": "A",
+	}))
+	docs := httpfs.New(mapfs.New(map[string]string{
+		"index.html": "B",
+	}))
+	version := httpfs.New(mapfs.New(map[string]string{
+		"version.json": "B",
+	}))
+	mounts := []vfsgen.Mount{
+		{At: "/", FS: build},
+		{At: "/docs", FS: docs},
+		{At: "/", FS: version},
+	}
+
+	tests := []struct {
+		conflict    vfsgen.Conflict
+		wantError   bool
+		wantVersion string
+	}{
+		{conflict: vfsgen.ConflictError, wantError: true},
+		{conflict: vfsgen.ConflictFirstWins, wantVersion: "A"},
+		{conflict: vfsgen.ConflictLastWins, wantVersion: "B"},
+	}
+	for _, tc := range tests {
+		filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+		err := vfsgen.GenerateMounts(mounts, vfsgen.Options{
+			Filename: filename,
+			Conflict: tc.conflict,
+		})
+		if tc.wantError {
+			if err == nil {
+				t.Errorf("conflict %v: got nil error, want non-nil", tc.conflict)
+			}
 			continue
 		}
+		if err != nil {
+			t.Fatalf("conflict %v: %v", tc.conflict, err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := string(b)
+		for _, want := range []string{
+			contentDef("/index.html", "A"),
+			contentDef("/docs/index.html", "B"),
+			contentDef("/version.json", tc.wantVersion),
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("conflict %v: generated code doesn't contain %q, but it should", tc.conflict, want)
+			}
+		}
+	}
+}
+
+// contentDef returns the beginning of the generated definition of a single-byte uncompressed file.
+func contentDef(path, content string) string {
+	return fmt.Sprintf("%q: &vfsgen۰FileInfo{\n\t\t\tname:    %q,\n\t\t\tmodTime: time.Time{},\n\t\t\tcontent: []byte(\"\\x%x\"),", path, filepath.Base(path), content)
+}
 
+// Verify that multiple generated filesystems with distinct type prefixes
+// can be built as part of the same package.
+func TestGenerate_typePrefix(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var filenames []string
+	for _, name := range []string{"assets", "docs"} {
+		filename := filepath.Join(tempDir, name+"_vfsdata.go")
+		err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+			"not-compressable-file.txt": "Not compressable.",
+			"compressable-file.txt":     "This text compresses easily. " + strings.Repeat(" Go!", 128),
+		})), vfsgen.Options{
+			Filename:     filename,
+			PackageName:  "test",
+			VariableName: name,
+			TypePrefix:   "vfsgen۰" + name + "۰",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		filenames = append(filenames, filename)
+	}
+	if out, err := exec.Command("go", append([]string{"build"}, filenames...)...).CombinedOutput(); err != nil {
+		t.Errorf("err: %v\nout: %s", err, out)
+	}
+
+	err := vfsgen.Generate(union.New(nil), vfsgen.Options{
+		Filename:   filepath.Join(tempDir, "invalid.go"),
+		TypePrefix: "vfsgen-",
+	})
+	if err == nil {
+		t.Error("got nil error for invalid type prefix, want non-nil")
+	}
+}
+
+func TestGenerate_templates(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+		"not-compressable-file.txt": "Not compressable.",
+	})), vfsgen.Options{
+		Filename:    filename,
+		PackageName: "test",
+		TypePrefix:  "vfsgen۰assets۰",
+		Templates: `{{define "Banner"}}// Copyright (c) Example Authors.
+
+{{end}}{{define "Footer"}}
+// Path returns the path of the file.
+func (f *vfsgen۰FileInfo) Path() string { return "/" + f.name }
+{{end}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "// Copyright (c) Example Authors.\n\n// Code generated by vfsgen; DO NOT EDIT.\n") {
+		t.Errorf("generated code doesn't start with banner:\n%s", b)
+	}
+	if !strings.Contains(string(b), "func (f *vfsgen۰assets۰FileInfo) Path() string") {
+		t.Error("generated code doesn't contain the method from Footer template")
+	}
+	if out, err := exec.Command("go", "build", filename).CombinedOutput(); err != nil {
+		t.Errorf("err: %v\nout: %s", err, out)
+	}
+
+	err = vfsgen.Generate(union.New(nil), vfsgen.Options{
+		Filename:  filename,
+		Templates: `{{define "Header"}}`,
+	})
+	if err == nil {
+		t.Error("got nil error for invalid templates, want non-nil")
+	}
+}
+
+func TestGenerate_transform(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+		"a.txt":      "A",
+		"data.json":  "{\n\t\"a\": \"" + strings.Repeat("Go!", 128) + "\"\n}\n",
+		"skip.other": "A",
+	})), vfsgen.Options{
+		Filename: filename,
+		Transform: []vfsgen.Transform{
+			func(path string, r io.Reader) (io.Reader, error) {
+				if path != "/a.txt" {
+					return r, nil
+				}
+				return strings.NewReader("B"), nil
+			},
+			vfsgen.MinifyJSON,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		contentDef("/a.txt", "B"),
+		contentDef("/skip.other", "A"),
+		fmt.Sprintf("uncompressedSize: %d,", len(`{"a":""}`)+3*128),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code doesn't contain %q, but it should", want)
+		}
+	}
+}
+
+func TestGenerate_pathConstants(t *testing.T) {
+	tempDir := t.TempDir()
+	mainFilename := filepath.Join(tempDir, "main.go")
+	err := os.WriteFile(mainFilename, []byte(`package main
+
+import "fmt"
+
+func main() {
+	fmt.Printf("%s", AssetsMustRead(AssetsPath_a_b_c_txt))
+	fmt.Println(AssetsOpen(AssetsPath_a_b))
+	fmt.Println(AssetsOpen(AssetsPath_long_file_name_txt))
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, runtimeImport := range []bool{false, true} {
+		filename := filepath.Join(tempDir, "assets_vfsdata.go")
+		err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+			"a-b/c.txt":          "Not compressable.",
+			"long-file-name.txt": "This text compresses easily. " + strings.Repeat(" Go!", 128),
+			"x/y/z.txt":          "",
+		})), vfsgen.Options{
+			Filename:      filename,
+			PathConstants: true,
+			RuntimeImport: runtimeImport,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command("go", "build", "-o", os.DevNull, filename, mainFilename).CombinedOutput(); err != nil {
+			t.Errorf("RuntimeImport=%v: err: %v\nout: %s", runtimeImport, err, out)
+		}
+		if out, err := exec.Command("gofmt", "-d", "-s", filename).Output(); err != nil || len(out) != 0 {
+			t.Errorf("RuntimeImport=%v: gofmt issue\nerr: %v\nout: %s", runtimeImport, err, out)
+		}
+
+		// Empty filesystem.
+		filename = filepath.Join(tempDir, "empty.go")
+		err = vfsgen.Generate(union.New(nil), vfsgen.Options{
+			Filename:      filename,
+			PackageName:   "test",
+			PathConstants: true,
+			RuntimeImport: runtimeImport,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
 		if out, err := exec.Command("go", "build", filename).CombinedOutput(); err != nil {
-			t.Errorf("err: %v\nout: %s", err, out)
+			t.Errorf("RuntimeImport=%v: err: %v\nout: %s", runtimeImport, err, out)
 		}
 		if out, err := exec.Command("gofmt", "-d", "-s", filename).Output(); err != nil || len(out) != 0 {
-			t.Errorf("gofmt issue\nerr: %v\nout: %s", err, out)
+			t.Errorf("RuntimeImport=%v: gofmt issue\nerr: %v\nout: %s", runtimeImport, err, out)
+		}
+	}
+
+	err = vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+		"a-b.txt": "",
+		"a_b.txt": "",
+	})), vfsgen.Options{
+		Filename:      filepath.Join(tempDir, "collision.go"),
+		PathConstants: true,
+	})
+	if err == nil {
+		t.Error("got nil error for paths with the same constant name, want non-nil")
+	}
+}
+
+func TestGenerate_manifest(t *testing.T) {
+	tempDir := t.TempDir()
+	compressable := "This text compresses easily. " + strings.Repeat(" Go!", 128)
+	err := vfsgen.Generate(httpfs.New(mapfs.New(map[string]string{
+		"folder/not-compressable-file.txt": "Not compressable.",
+		"compressable-file.txt":            compressable,
+	})), vfsgen.Options{
+		Filename:         filepath.Join(tempDir, "assets_vfsdata.go"),
+		ManifestFilename: filepath.Join(tempDir, "assets_manifest.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(tempDir, "assets_manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m vfsgen.Manifest
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, e := range m.Entries {
+		paths = append(paths, e.Path)
+	}
+	if got, want := strings.Join(paths, " "), "/ /compressable-file.txt /folder /folder/not-compressable-file.txt"; got != want {
+		t.Fatalf("got paths %q, want %q", got, want)
+	}
+	if e := m.Entries[0]; !e.Dir || e.Mode != 0755|os.ModeDir {
+		t.Errorf("got root entry %+v, want a directory", e)
+	}
+	if e := m.Entries[1]; e.Encoding != "gzip" || e.Size != int64(len(compressable)) || e.StoredSize >= e.Size ||
+		e.SHA256 != fmt.Sprintf("%x", sha256.Sum256([]byte(compressable))) {
+		t.Errorf("got compressed file entry %+v", e)
+	}
+	if e := m.Entries[3]; e.Encoding != "identity" || e.Size != 17 || e.StoredSize != 17 ||
+		e.SHA256 != fmt.Sprintf("%x", sha256.Sum256([]byte("Not compressable."))) {
+		t.Errorf("got uncompressed file entry %+v", e)
+	}
+}
+
+// Verify that the compression options select the type of file definitions,
+// and that an invalid CompressionLevel is rejected.
+func TestGenerate_compression(t *testing.T) {
+	compressable := "This text compresses easily. " + strings.Repeat(" Go!", 128)
+	input := httpfs.New(mapfs.New(map[string]string{
+		"compressable-file.txt": compressable,
+	}))
+	tests := []struct {
+		name string
+		opt  vfsgen.Options
+		want string // Type of the file definition.
+	}{
+		{name: "default", want: "&vfsgen۰CompressedFileInfo{"},
+		{name: "level", opt: vfsgen.Options{CompressionLevel: gzip.BestSpeed}, want: "&vfsgen۰CompressedFileInfo{"},
+		{name: "none", opt: vfsgen.Options{NoCompression: true}, want: "&vfsgen۰FileInfo{"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opt.Filename = filepath.Join(t.TempDir(), "assets_vfsdata.go")
+			err := vfsgen.Generate(input, tc.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(tc.opt.Filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(b, []byte(`"/compressable-file.txt": `+tc.want)) {
+				t.Errorf("generated code doesn't contain %q:\n%s", tc.want, b)
+			}
+		})
+	}
+
+	err := vfsgen.Generate(input, vfsgen.Options{
+		Filename:         filepath.Join(t.TempDir(), "assets_vfsdata.go"),
+		CompressionLevel: 42,
+	})
+	if err == nil {
+		t.Error("got nil error for invalid CompressionLevel, want non-nil")
+	}
+}
+
+func TestGenerate_cache(t *testing.T) {
+	compressable := "This text compresses easily. " + strings.Repeat(" Go!", 128)
+	input := httpfs.New(mapfs.New(map[string]string{
+		"not-compressable-file.txt": "Not compressable.",
+		"compressable-file.txt":     compressable,
+	}))
+	generate := func(cacheDir string) []byte {
+		t.Helper()
+		filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+		err := vfsgen.Generate(input, vfsgen.Options{Filename: filename, CacheDir: cacheDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	want := generate("")
+
+	// The generated code is the same with an empty and with a populated cache.
+	cacheDir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		if got := generate(cacheDir); !bytes.Equal(got, want) {
+			t.Fatalf("generation %d with cache differs from generation without cache", i)
+		}
+	}
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d cache entries, want 2", len(entries))
+	}
+
+	// Cached content is reused rather than compressed again. The entry is replaced
+	// with content compressed at a different level, which is equally valid.
+	var buf bytes.Buffer
+	gw, err := gzip.NewWriterLevel(&buf, gzip.HuffmanOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw.Write([]byte(compressable))
+	gw.Close()
+	for _, e := range entries {
+		if fi, err := os.Stat(e); err == nil && fi.Size() > 0 {
+			err = os.WriteFile(e, buf.Bytes(), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var lit strings.Builder // The content as written by the generated code.
+	for _, b := range buf.Bytes() {
+		fmt.Fprintf(&lit, `\x%02x`, b)
+	}
+	if got := generate(cacheDir); !strings.Contains(string(got), lit.String()) {
+		t.Errorf("generated code doesn't contain content from modified cache entry:\n%s", got)
+	}
+
+	// Damaged cache entries are replaced rather than used.
+	for _, damaged := range [][]byte{[]byte("not gzip"), buf.Bytes()[:buf.Len()/2]} {
+		for _, e := range entries {
+			if fi, err := os.Stat(e); err == nil && fi.Size() > 0 {
+				err = os.WriteFile(e, damaged, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if got := generate(cacheDir); !bytes.Equal(got, want) {
+			t.Errorf("generation with damaged cache entry %.8q differs from generation without cache", damaged)
+		}
+		if got := generate(cacheDir); !bytes.Equal(got, want) {
+			t.Errorf("generation with replaced cache entry %.8q differs from generation without cache", damaged)
+		}
+	}
+}
+
+func TestGenerate_modTime(t *testing.T) {
+	inputDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte("Hello."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		policy   vfsgen.ModTimePolicy
+		wantDate bool
+	}{
+		{policy: vfsgen.ModTimeKeep, wantDate: true},
+		{policy: vfsgen.ModTimeZero, wantDate: false},
+	} {
+		filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+		err := vfsgen.Generate(http.Dir(inputDir), vfsgen.Options{
+			Filename: filename,
+			ModTime:  tc.policy,
+		})
+		if err != nil {
+			t.Fatal(err)
 		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bytes.Contains(b, []byte("time.Date(")); got != tc.wantDate {
+			t.Errorf("policy %v: got modification time in generated code %v, want %v", tc.policy, got, tc.wantDate)
+		}
+	}
+}
+
+func TestGenerate_budget(t *testing.T) {
+	input := httpfs.New(mapfs.New(map[string]string{
+		"small.txt":       "Small.",     // Stored uncompressed, 6 bytes.
+		"big.txt":         "Big file.",  // Stored uncompressed, 9 bytes.
+		"video/intro.mp4": "Not video.", // Stored uncompressed, 10 bytes.
+	}))
+	tests := []struct {
+		name   string
+		budget vfsgen.Budget
+		want   []vfsgen.BudgetViolation // Nil means no error.
+	}{
+		{
+			name:   "within",
+			budget: vfsgen.Budget{MaxTotalSize: 25, MaxFileSize: 10, Limits: []vfsgen.BudgetLimit{{Pattern: "*.mp4", MaxSize: 10}}},
+		},
+		{
+			name:   "total",
+			budget: vfsgen.Budget{MaxTotalSize: 24},
+			want: []vfsgen.BudgetViolation{{Limit: "total size", MaxSize: 24, Size: 25, Files: []vfsgen.BudgetFile{
+				{Path: "/video/intro.mp4", Size: 10}, {Path: "/big.txt", Size: 9}, {Path: "/small.txt", Size: 6},
+			}}},
+		},
+		{
+			name:   "file",
+			budget: vfsgen.Budget{MaxFileSize: 8},
+			want: []vfsgen.BudgetViolation{
+				{Limit: "file size", MaxSize: 8, Size: 10, Files: []vfsgen.BudgetFile{{Path: "/video/intro.mp4", Size: 10}}},
+				{Limit: "file size", MaxSize: 8, Size: 9, Files: []vfsgen.BudgetFile{{Path: "/big.txt", Size: 9}}},
+			},
+		},
+		{
+			name:   "pattern",
+			budget: vfsgen.Budget{Limits: []vfsgen.BudgetLimit{{Pattern: "*.mp4", MaxSize: 0}, {Pattern: "*.txt", MaxSize: 15}}},
+			want: []vfsgen.BudgetViolation{
+				{Limit: "*.mp4", MaxSize: 0, Size: 10, Files: []vfsgen.BudgetFile{{Path: "/video/intro.mp4", Size: 10}}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+			err := vfsgen.Generate(input, vfsgen.Options{Filename: filename, Budget: tc.budget})
+			if tc.want == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			be, ok := err.(*vfsgen.BudgetError)
+			if !ok {
+				t.Fatalf("got error %v, want *vfsgen.BudgetError", err)
+			}
+			if got, want := fmt.Sprint(be.Violations), fmt.Sprint(tc.want); got != want {
+				t.Errorf("got violations:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := os.Stat(filename); !os.IsNotExist(err) {
+				t.Errorf("got output file written despite exceeded budget, stat error %v", err)
+			}
+		})
+	}
+
+	err := vfsgen.Generate(input, vfsgen.Options{
+		Filename: filepath.Join(t.TempDir(), "assets_vfsdata.go"),
+		Budget:   vfsgen.Budget{Limits: []vfsgen.BudgetLimit{{Pattern: "[", MaxSize: 1}}},
+	})
+	if err == nil {
+		t.Error("got nil error for invalid budget pattern, want non-nil")
+	}
+}
+
+func TestGenerate_sharedContent(t *testing.T) {
+	compressable := "This text compresses easily. " + strings.Repeat(" Go!", 128)
+	files := map[string]string{
+		"theme1/logo.txt": compressable,
+		"theme2/logo.txt": compressable,
+		"theme3/logo.txt": compressable,
+		"LICENSE":         notCompressable,
+		"vendor/LICENSE":  notCompressable,
+		"unique.txt":      notCompressable + "!",
+	}
+	for _, runtimeImport := range []bool{false, true} {
+		filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+		manifestFilename := filepath.Join(t.TempDir(), "manifest.json")
+		err := vfsgen.Generate(httpfs.New(mapfs.New(files)), vfsgen.Options{
+			Filename:         filename,
+			ManifestFilename: manifestFilename,
+			RuntimeImport:    runtimeImport,
+			Budget:           vfsgen.Budget{MaxTotalSize: int64(2*len(notCompressable) + 1 + 100)},
+		})
+		if err != nil {
+			t.Fatalf("RuntimeImport=%v: %v", runtimeImport, err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Each distinct content is written once, in a variable for shared content.
+		if got, want := bytes.Count(b, []byte(`[]byte("`)), 3; got != want {
+			t.Errorf("RuntimeImport=%v: got %d content literals in generated code, want %d", runtimeImport, got, want)
+		}
+		if got, want := bytes.Count(b, []byte("var vfsgen۰assets۰sharedContent")), 2; got != want {
+			t.Errorf("RuntimeImport=%v: got %d shared content variables in generated code, want %d", runtimeImport, got, want)
+		}
+
+		fs, err := vfsgen.LoadGenerated(filename)
+		if err != nil {
+			t.Fatalf("RuntimeImport=%v: %v", runtimeImport, err)
+		}
+		for path, want := range files {
+			got, err := vfsutil.ReadFile(fs, "/"+path)
+			if err != nil {
+				t.Fatalf("RuntimeImport=%v: %v", runtimeImport, err)
+			}
+			if string(got) != want {
+				t.Errorf("RuntimeImport=%v: %s: got content %q, want %q", runtimeImport, path, got, want)
+			}
+		}
+
+		// The manifest describes each file with its own path and the shared content.
+		mb, err := os.ReadFile(manifestFilename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m vfsgen.Manifest
+		err = json.Unmarshal(mb, &m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sums := make(map[string]string) // Path -> SHA-256 hash.
+		for _, e := range m.Entries {
+			sums[e.Path] = e.SHA256
+		}
+		if sums["/LICENSE"] == "" || sums["/LICENSE"] != sums["/vendor/LICENSE"] {
+			t.Errorf("RuntimeImport=%v: got hashes %q and %q for files with shared content, want equal", runtimeImport, sums["/LICENSE"], sums["/vendor/LICENSE"])
+		}
+	}
+}
+
+// Verify that transforms are applied once to each file, and that generation fails
+// if a file with shared content changes after it's found to be shared.
+func TestGenerate_sharedContentChanged(t *testing.T) {
+	files := map[string]string{
+		"a.txt": notCompressable,
+		"b.txt": notCompressable,
+	}
+	calls := make(map[string]int) // Path -> number of times the transform is applied.
+	err := vfsgen.Generate(httpfs.New(mapfs.New(files)), vfsgen.Options{
+		Filename: filepath.Join(t.TempDir(), "assets_vfsdata.go"),
+		Transform: []vfsgen.Transform{func(path string, r io.Reader) (io.Reader, error) {
+			calls[path]++
+			return r, nil
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path := range files {
+		if got, want := calls["/"+path], 1; got != want {
+			t.Errorf("%s: transform applied %d times, want %d", path, got, want)
+		}
+	}
+
+	fs := &changingFS{
+		FileSystem: httpfs.New(mapfs.New(files)),
+		after:      httpfs.New(mapfs.New(map[string]string{"a.txt": strings.ToUpper(notCompressable)})),
+		path:       "/a.txt",
+	}
+	err = vfsgen.Generate(fs, vfsgen.Options{Filename: filepath.Join(t.TempDir(), "assets_vfsdata.go")})
+	if err == nil || !strings.Contains(err.Error(), "/a.txt: file changed during generation") {
+		t.Errorf("got error %v, want one about /a.txt changing", err)
+	}
+}
+
+// changingFS is a filesystem whose file at path has the content from after
+// once it's been read in full.
+type changingFS struct {
+	http.FileSystem
+	after   http.FileSystem
+	path    string
+	changed bool
+}
+
+func (fs *changingFS) Open(name string) (http.File, error) {
+	if name != fs.path {
+		return fs.FileSystem.Open(name)
+	}
+	if fs.changed {
+		return fs.after.Open(name)
+	}
+	f, err := fs.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &changingFile{File: f, fs: fs}, nil
+}
+
+type changingFile struct {
+	http.File
+	fs *changingFS
+}
+
+func (f *changingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	if err == io.EOF {
+		f.fs.changed = true
 	}
+	return n, err
 }
@@ -1,3 +1,20 @@
 module github.com/shurcooL/vfsgen
 
-go 1.19
+// Go 1.22 is required by golang.org/x/tools, which cmd/vfsgendev uses to load
+// source packages. Its releases that support earlier Go versions don't build
+// with current Go releases.
+go 1.22.0
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/shurcooL/httpfs v0.0.0-20230704072500-f1e31cf0ba5c
+	github.com/shurcooL/httpgzip v0.0.0-20190720172056-320755c1c1b0
+	golang.org/x/tools v0.29.0
+)
+
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
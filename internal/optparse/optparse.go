@@ -0,0 +1,89 @@
+// Package optparse parses the values of flags and configuration keys for vfsgen
+// options, so that the vfsgen and vfsgendev commands accept the same values.
+//
+// Values are returned along with the names of the vfsgen identifiers that
+// they refer to, for vfsgendev to use in the generator program it writes.
+package optparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+// ModTime is a modification time policy.
+type ModTime struct {
+	Name   string // Name of the vfsgen constant.
+	Policy vfsgen.ModTimePolicy
+}
+
+// modTimes are the modification time policies by their names in flag values.
+var modTimes = map[string]ModTime{
+	"keep": {"ModTimeKeep", vfsgen.ModTimeKeep},
+	"zero": {"ModTimeZero", vfsgen.ModTimeZero},
+}
+
+// ParseModTime parses the name of a modification time policy: "keep" or "zero".
+// An empty name is "keep".
+func ParseModTime(s string) (ModTime, error) {
+	if s == "" {
+		s = "keep"
+	}
+	m, ok := modTimes[s]
+	if !ok {
+		return ModTime{}, fmt.Errorf("unknown modification time policy %q", s)
+	}
+	return m, nil
+}
+
+// Minifier is a built-in transform that minifies a type of files.
+type Minifier struct {
+	Name      string // Name of the vfsgen variable.
+	Transform vfsgen.Transform
+}
+
+// minifiers are the minifying transforms by the names of their file types, in order.
+var minifiers = []struct {
+	typ string
+	Minifier
+}{
+	{"json", Minifier{"MinifyJSON", vfsgen.MinifyJSON}},
+	{"css", Minifier{"MinifyCSS", vfsgen.MinifyCSS}},
+	{"html", Minifier{"MinifyHTML", vfsgen.MinifyHTML}},
+	{"svg", Minifier{"MinifySVG", vfsgen.MinifySVG}},
+}
+
+// ParseMinify returns the minifiers for the given file types, in order. A type is
+// "json", "css", "html", "svg", or "all" for all of them. Spaces around types are ignored.
+func ParseMinify(types []string) ([]Minifier, error) {
+	var ms []Minifier
+	for _, typ := range types {
+		typ := strings.TrimSpace(typ)
+		n := len(ms)
+		for _, m := range minifiers {
+			if typ == m.typ || typ == "all" {
+				ms = append(ms, m.Minifier)
+			}
+		}
+		if len(ms) == n {
+			return nil, fmt.Errorf("unknown file type %q", typ)
+		}
+	}
+	return ms, nil
+}
+
+// ParseLimit parses a budget limit of the form "pattern=bytes". The pattern
+// ends at the last "=", so that it can contain "=" itself.
+func ParseLimit(s string) (vfsgen.BudgetLimit, error) {
+	i := strings.LastIndex(s, "=")
+	if i == -1 {
+		return vfsgen.BudgetLimit{}, fmt.Errorf("%q is not of the form pattern=bytes", s)
+	}
+	maxSize, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return vfsgen.BudgetLimit{}, err
+	}
+	return vfsgen.BudgetLimit{Pattern: s[:i], MaxSize: maxSize}, nil
+}
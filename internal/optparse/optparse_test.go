@@ -0,0 +1,84 @@
+package optparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+func TestParseModTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    vfsgen.ModTimePolicy
+		wantErr bool
+	}{
+		{in: "", want: vfsgen.ModTimeKeep},
+		{in: "keep", want: vfsgen.ModTimeKeep},
+		{in: "zero", want: vfsgen.ModTimeZero},
+		{in: "now", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseModTime(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("ParseModTime(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got.Policy != tc.want {
+			t.Errorf("ParseModTime(%q): got policy %v, want %v", tc.in, got.Policy, tc.want)
+		}
+	}
+}
+
+func TestParseMinify(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "json", want: []string{"MinifyJSON"}},
+		{in: "css, html", want: []string{"MinifyCSS", "MinifyHTML"}},
+		{in: "all", want: []string{"MinifyJSON", "MinifyCSS", "MinifyHTML", "MinifySVG"}},
+		{in: "svg,json", want: []string{"MinifySVG", "MinifyJSON"}},
+		{in: "xml", wantErr: true},
+		{in: "json,", wantErr: true},
+	}
+	for _, tc := range tests {
+		ms, err := ParseMinify(strings.Split(tc.in, ","))
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("ParseMinify(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		var got []string
+		for _, m := range ms {
+			got = append(got, m.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseMinify(%q): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    vfsgen.BudgetLimit
+		wantErr bool
+	}{
+		{in: "*.png=1000", want: vfsgen.BudgetLimit{Pattern: "*.png", MaxSize: 1000}},
+		{in: "a=b=0", want: vfsgen.BudgetLimit{Pattern: "a=b", MaxSize: 0}},
+		{in: "*.png", wantErr: true},
+		{in: "*.png=big", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseLimit(tc.in)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("ParseLimit(%q): got error %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseLimit(%q): got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
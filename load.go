@@ -0,0 +1,345 @@
+package vfsgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/vfsgen/vfsgenrt"
+)
+
+// LoadGenerated parses the Go file at path, which must contain code generated
+// by vfsgen with the default templates, and returns the filesystem it implements.
+// The generated code is decoded without being compiled or executed.
+func LoadGenerated(path string) (http.FileSystem, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := decodeSharedContents(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fset.Position(errPos(err)), err)
+	}
+
+	fs := make(vfsgenrt.FS)
+	entries := make(map[string][]string) // Directory path -> entry paths.
+	ast.Inspect(f, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.KeyValueExpr:
+			// A definition, such as "/path": &vfsgen۰FileInfo{...}.
+			var path string
+			var v interface{}
+			path, v, err = decodeDefinition(n, vars)
+			if err == nil && v != nil {
+				fs[path] = v
+				return false
+			}
+		case *ast.AssignStmt:
+			// Directory entries, such as fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{...}.
+			var dir string
+			var paths []string
+			dir, paths, err = decodeEntries(n)
+			if err == nil && paths != nil {
+				entries[dir] = paths
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fset.Position(errPos(err)), err)
+	}
+
+	if _, ok := fs["/"].(*vfsgenrt.DirInfo); !ok {
+		return nil, fmt.Errorf("%s: no generated filesystem found", path)
+	}
+	for dir, paths := range entries {
+		d, ok := fs[dir].(*vfsgenrt.DirInfo)
+		if !ok {
+			return nil, fmt.Errorf("%s: entries of %q, which is not a directory", path, dir)
+		}
+		for _, p := range paths {
+			fi, ok := fs[p].(os.FileInfo)
+			if !ok {
+				return nil, fmt.Errorf("%s: entry %q of %q is not defined", path, p, dir)
+			}
+			d.Entries = append(d.Entries, fi)
+		}
+	}
+	return fs, nil
+}
+
+// loadError is an error decoding generated code at a position.
+type loadError struct {
+	pos token.Pos
+	msg string
+}
+
+func (e loadError) Error() string { return e.msg }
+
+func errPos(err error) token.Pos {
+	if e, ok := err.(loadError); ok {
+		return e.pos
+	}
+	return token.NoPos
+}
+
+// decodeSharedContents decodes the package-level declarations of variables
+// that hold content shared by files, such as var x = []byte("...").
+// It returns the content of each variable by name.
+func decodeSharedContents(f *ast.File) (map[string][]byte, error) {
+	vars := make(map[string][]byte)
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if len(vs.Names) != 1 || len(vs.Values) != 1 {
+				continue
+			}
+			if call, ok := vs.Values[0].(*ast.CallExpr); !ok || len(call.Args) != 1 {
+				continue
+			} else if _, ok := call.Fun.(*ast.ArrayType); !ok {
+				continue
+			}
+			b, err := decodeBytes(vs.Values[0])
+			if err != nil {
+				return nil, err
+			}
+			vars[vs.Names[0].Name] = b
+		}
+	}
+	return vars, nil
+}
+
+// decodeDefinition decodes a file or directory definition. It returns a nil value
+// if kv is not such a definition. Content that refers to a variable is looked up in vars.
+func decodeDefinition(kv *ast.KeyValueExpr, vars map[string][]byte) (path string, v interface{}, err error) {
+	key, ok := kv.Key.(*ast.BasicLit)
+	if !ok || key.Kind != token.STRING {
+		return "", nil, nil
+	}
+	ue, ok := kv.Value.(*ast.UnaryExpr)
+	if !ok || ue.Op != token.AND {
+		return "", nil, nil
+	}
+	cl, ok := ue.X.(*ast.CompositeLit)
+	if !ok {
+		return "", nil, nil
+	}
+	var (
+		cfi *vfsgenrt.CompressedFileInfo
+		fi  *vfsgenrt.FileInfo
+		di  *vfsgenrt.DirInfo
+	)
+	switch typeName := typeName(cl.Type); {
+	case strings.HasSuffix(typeName, "CompressedFileInfo"):
+		cfi = new(vfsgenrt.CompressedFileInfo)
+		v = cfi
+	case strings.HasSuffix(typeName, "FileInfo"):
+		fi = new(vfsgenrt.FileInfo)
+		v = fi
+	case strings.HasSuffix(typeName, "DirInfo"):
+		di = new(vfsgenrt.DirInfo)
+		v = di
+	default:
+		return "", nil, nil
+	}
+	path, err = strconv.Unquote(key.Value)
+	if err != nil {
+		return "", nil, loadError{key.Pos(), err.Error()}
+	}
+
+	for _, elt := range cl.Elts {
+		field, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return "", nil, loadError{elt.Pos(), "unexpected element in definition"}
+		}
+		id, ok := field.Key.(*ast.Ident)
+		if !ok {
+			return "", nil, loadError{field.Pos(), "unexpected field key in definition"}
+		}
+		switch name := strings.ToLower(id.Name); {
+		case name == "name" || name == "basename":
+			var s string
+			s, err = decodeString(field.Value)
+			switch {
+			case cfi != nil:
+				cfi.BaseName = s
+			case fi != nil:
+				fi.BaseName = s
+			default:
+				di.BaseName = s
+			}
+		case name == "modtime" || name == "modifiedtime":
+			var t time.Time
+			t, err = decodeTime(field.Value)
+			switch {
+			case cfi != nil:
+				cfi.ModifiedTime = t
+			case fi != nil:
+				fi.ModifiedTime = t
+			default:
+				di.ModifiedTime = t
+			}
+		case name == "uncompressedsize" && cfi != nil:
+			cfi.UncompressedSize, err = decodeInt(field.Value)
+		case name == "compressedcontent" && cfi != nil:
+			cfi.CompressedContent, err = decodeContent(field.Value, vars)
+		case name == "content" && fi != nil:
+			fi.Content, err = decodeContent(field.Value, vars)
+		default:
+			err = loadError{id.Pos(), fmt.Sprintf("unexpected field %s in definition", id.Name)}
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return path, v, nil
+}
+
+// decodeEntries decodes an assignment of directory entries. It returns nil paths
+// if as is not such an assignment.
+func decodeEntries(as *ast.AssignStmt) (dir string, paths []string, err error) {
+	if len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+		return "", nil, nil
+	}
+	sel, ok := as.Lhs[0].(*ast.SelectorExpr)
+	if !ok || !strings.EqualFold(sel.Sel.Name, "entries") {
+		return "", nil, nil
+	}
+	ta, ok := sel.X.(*ast.TypeAssertExpr)
+	if !ok {
+		return "", nil, nil
+	}
+	dir, err = decodeIndex(ta.X)
+	if err != nil {
+		return "", nil, err
+	}
+	cl, ok := as.Rhs[0].(*ast.CompositeLit)
+	if !ok {
+		return "", nil, loadError{as.Rhs[0].Pos(), "unexpected directory entries"}
+	}
+	paths = []string{}
+	for _, elt := range cl.Elts {
+		ta, ok := elt.(*ast.TypeAssertExpr)
+		if !ok {
+			return "", nil, loadError{elt.Pos(), "unexpected directory entry"}
+		}
+		p, err := decodeIndex(ta.X)
+		if err != nil {
+			return "", nil, err
+		}
+		paths = append(paths, p)
+	}
+	return dir, paths, nil
+}
+
+// typeName returns the name of a type expression, without the package qualifier if any.
+func typeName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	default:
+		return ""
+	}
+}
+
+// decodeIndex decodes an index expression with a string literal index, such as fs["/path"].
+func decodeIndex(e ast.Expr) (string, error) {
+	ie, ok := e.(*ast.IndexExpr)
+	if !ok {
+		return "", loadError{e.Pos(), "not an index expression"}
+	}
+	return decodeString(ie.Index)
+}
+
+func decodeString(e ast.Expr) (string, error) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", loadError{e.Pos(), "not a string literal"}
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", loadError{e.Pos(), err.Error()}
+	}
+	return s, nil
+}
+
+func decodeInt(e ast.Expr) (int64, error) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, loadError{e.Pos(), "not an integer literal"}
+	}
+	n, err := strconv.ParseInt(lit.Value, 0, 64)
+	if err != nil {
+		return 0, loadError{e.Pos(), err.Error()}
+	}
+	return n, nil
+}
+
+// decodeBytes decodes a conversion of a string literal to []byte.
+func decodeBytes(e ast.Expr) ([]byte, error) {
+	call, ok := e.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, loadError{e.Pos(), "not a []byte conversion"}
+	}
+	if _, ok := call.Fun.(*ast.ArrayType); !ok {
+		return nil, loadError{e.Pos(), "not a []byte conversion"}
+	}
+	s, err := decodeString(call.Args[0])
+	return []byte(s), err
+}
+
+// decodeContent decodes a conversion of a string literal to []byte,
+// or a reference to a variable in vars.
+func decodeContent(e ast.Expr, vars map[string][]byte) ([]byte, error) {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return decodeBytes(e)
+	}
+	b, ok := vars[id.Name]
+	if !ok {
+		return nil, loadError{e.Pos(), fmt.Sprintf("content variable %s is not declared", id.Name)}
+	}
+	return b, nil
+}
+
+// decodeTime decodes time.Time{} or a time.Date call with integer literal arguments in UTC.
+func decodeTime(e ast.Expr) (time.Time, error) {
+	switch e := e.(type) {
+	case *ast.CompositeLit:
+		if len(e.Elts) == 0 && typeName(e.Type) == "Time" {
+			return time.Time{}, nil
+		}
+	case *ast.CallExpr:
+		if typeName(e.Fun) != "Date" || len(e.Args) != 8 {
+			break
+		}
+		var v [7]int64
+		for i := range v {
+			n, err := decodeInt(e.Args[i])
+			if err != nil {
+				return time.Time{}, err
+			}
+			v[i] = n
+		}
+		return time.Date(int(v[0]), time.Month(v[1]), int(v[2]), int(v[3]), int(v[4]), int(v[5]), int(v[6]), time.UTC), nil
+	}
+	return time.Time{}, loadError{e.Pos(), "not a time literal"}
+}
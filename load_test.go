@@ -0,0 +1,73 @@
+package vfsgen_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+	"github.com/shurcooL/vfsgen"
+	"golang.org/x/tools/godoc/vfs/httpfs"
+	"golang.org/x/tools/godoc/vfs/mapfs"
+)
+
+func TestLoadGenerated(t *testing.T) {
+	files := map[string]string{
+		"not-compressable-file.txt":    "Not compressable.",
+		"folder/compressable-file.txt": "This text compresses easily. " + strings.Repeat(" Go!", 128),
+		"folder/sub/file.txt":          "File.",
+	}
+	input := httpfs.New(mapfs.New(files))
+
+	for _, opt := range []vfsgen.Options{
+		{},
+		{TypePrefix: "vfsgen۰assets۰"},
+		{RuntimeImport: true},
+	} {
+		opt.Filename = filepath.Join(t.TempDir(), "assets_vfsdata.go")
+		err := vfsgen.Generate(input, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, err := vfsgen.LoadGenerated(opt.Filename)
+		if err != nil {
+			t.Fatalf("%+v: %v", opt, err)
+		}
+
+		var paths []string
+		err = vfsutil.Walk(fs, "/", func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+			if fi.IsDir() {
+				return nil
+			}
+			b, err := vfsutil.ReadFile(fs, path)
+			if err != nil {
+				return err
+			}
+			if want := files[strings.TrimPrefix(path, "/")]; string(b) != want || fi.Size() != int64(len(want)) {
+				t.Errorf("%+v: %s: got %q (size %d), want %q", opt, path, b, fi.Size(), want)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(paths, " "), "/ /folder /folder/compressable-file.txt /folder/sub /folder/sub/file.txt /not-compressable-file.txt"; got != want {
+			t.Errorf("%+v: got paths %q, want %q", opt, got, want)
+		}
+	}
+
+	// The generated code in package test is decoded the same way.
+	fs, err := vfsgen.LoadGenerated(filepath.Join("test", "test_vfsdata_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := vfsutil.ReadFile(fs, "/folderB/folderC/file3.txt")
+	if got, want := string(b), "Stuff in /folderB/folderC/file3.txt."; err != nil || got != want {
+		t.Errorf("got %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,66 @@
+package vfsgen
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Manifest describes the contents of a generated filesystem.
+// It's written as JSON to Options.ManifestFilename.
+type Manifest struct {
+	Entries []ManifestEntry `json:"entries"` // Sorted by path.
+}
+
+// ManifestEntry describes a file or directory in a generated filesystem.
+type ManifestEntry struct {
+	Path       string      `json:"path"`
+	Dir        bool        `json:"dir,omitempty"`
+	Size       int64       `json:"size"`               // Uncompressed size of a file.
+	StoredSize int64       `json:"storedSize"`         // Size of a file's content in the generated code.
+	Encoding   string      `json:"encoding,omitempty"` // Encoding of a file's stored content, "gzip" or "identity".
+	ModTime    time.Time   `json:"modTime"`            // Modification time.
+	Mode       os.FileMode `json:"mode"`               // Mode in the generated filesystem.
+	SHA256     string      `json:"sha256,omitempty"`   // Hex-encoded SHA-256 hash of a file's uncompressed content.
+}
+
+// newManifest returns the manifest of all files and directories in toc.
+func newManifest(toc TOC) Manifest {
+	var m Manifest
+	for _, dir := range toc.Dirs {
+		m.Entries = append(m.Entries, ManifestEntry{
+			Path:    dir.Path,
+			Dir:     true,
+			ModTime: dir.ModTime,
+			Mode:    0755 | os.ModeDir,
+		})
+	}
+	for _, file := range toc.Files {
+		encoding := "identity"
+		if file.Compressed {
+			encoding = "gzip"
+		}
+		m.Entries = append(m.Entries, ManifestEntry{
+			Path:       file.Path,
+			Size:       file.UncompressedSize,
+			StoredSize: file.StoredSize,
+			Encoding:   encoding,
+			ModTime:    file.ModTime,
+			Mode:       0444,
+			SHA256:     hex.EncodeToString(file.SHA256[:]),
+		})
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	return m
+}
+
+// writeManifest writes the manifest of toc as JSON to a file with the given name.
+func writeManifest(filename string, toc TOC) error {
+	b, err := json.MarshalIndent(newManifest(toc), "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0644)
+}
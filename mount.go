@@ -0,0 +1,28 @@
+package vfsgen
+
+import "net/http"
+
+// Mount is an input filesystem placed at a path in the generated filesystem.
+type Mount struct {
+	// At is the path of the directory where the root of FS is placed.
+	// Parent directories are generated as needed, with a zero modification time.
+	At string
+
+	// FS is the input filesystem.
+	FS http.FileSystem
+}
+
+// Conflict specifies how a path that is provided by more than one mount is resolved.
+// Directories are never in conflict; their contents are merged.
+type Conflict int
+
+const (
+	// ConflictError makes generation fail with an error.
+	ConflictError Conflict = iota
+
+	// ConflictFirstWins keeps the entry from the mount that comes first.
+	ConflictFirstWins
+
+	// ConflictLastWins keeps the entry from the mount that comes last.
+	ConflictLastWins
+)
@@ -1,6 +1,7 @@
 package vfsgen
 
 import (
+	"compress/gzip"
 	"fmt"
 	"strings"
 )
@@ -11,6 +12,11 @@ type Options struct {
 	// If left empty, it defaults to "{{toLower .VariableName}}_vfsdata.go".
 	Filename string
 
+	// ManifestFilename is the optional filename of a JSON manifest to write
+	// alongside the generated Go code. It describes every file and directory
+	// in the generated filesystem, see Manifest.
+	ManifestFilename string
+
 	// PackageName is the name of the package in the generated code.
 	// If left empty, it defaults to "main".
 	PackageName string
@@ -26,8 +32,144 @@ type Options struct {
 	// VariableComment is the comment of the http.FileSystem variable in the generated code.
 	// If left empty, it defaults to "{{.VariableName}} statically implements the virtual filesystem provided to vfsgen.".
 	VariableComment string
+
+	// TypePrefix is the prefix of the names of the types declared in the generated code.
+	// If left empty, it defaults to "vfsgen۰". Each generated filesystem in the same
+	// package needs a distinct TypePrefix, for example, "vfsgen۰assets۰" and "vfsgen۰docs۰".
+	// It has no effect when RuntimeImport is set.
+	TypePrefix string
+
+	// RuntimeImport makes the generated code import the github.com/shurcooL/vfsgen/vfsgenrt
+	// package for the implementation of the filesystem, instead of declaring its own types.
+	// The generated code then contains only data, and multiple generated filesystems
+	// can be placed in the same package.
+	RuntimeImport bool
+
+	// PathConstants enables generating a path type with a constant for the path of
+	// each file and directory, and accessor functions that take the path type.
+	// For example, with VariableName "assets", the file "/static/css/app.css" gets
+	// the constant AssetsPath_static_css_app_css of type AssetsPath, which can be
+	// passed to the generated AssetsOpen and AssetsMustRead functions.
+	// That way, renaming or deleting a file causes a compile error in code that uses it.
+	PathConstants bool
+
+	// NoCompression disables gzip compression, so that all files are stored
+	// uncompressed in the generated code.
+	NoCompression bool
+
+	// CompressionLevel is the gzip compression level used for files, as accepted
+	// by gzip.NewWriterLevel. If left zero, it defaults to gzip.BestCompression.
+	// Files that don't get smaller when compressed are always stored uncompressed.
+	CompressionLevel int
+
+	// CacheDir is an optional directory in which gzip compressed file contents are
+	// cached, keyed by a hash of the content, CompressionLevel and the Go version that
+	// vfsgen is built with. Files with the same content as in a previous generation are
+	// not compressed again. The generated code is the same with or without a cache,
+	// for a given Go version; compressed output can differ between Go releases.
+	// Entries that don't decompress to the content, such as damaged ones, are
+	// replaced. The directory is created if needed.
+	CacheDir string
+
+	// Transform is an optional chain of transforms applied in order to the content
+	// of each file before it's compressed. Sizes in the generated code reflect the
+	// transformed content. Transforms are applied once to each file, and the
+	// transformed content of all files is held in memory during generation.
+	// See MinifyJSON, MinifyCSS, MinifyHTML and MinifySVG for built-in transforms.
+	Transform []Transform
+
+	// Templates is optional text/template source with {{define}} actions that
+	// override the named templates used to produce the generated code. They are
+	// executed in this order, with the given data:
+	//
+	// 	"Banner"                     Options        Empty by default; executed at the start of "Header".
+	// 	"Header"                     Options        Package clause, imports and start of the variable.
+	// 	"DirInfo"                    *DirInfo       Definition of each directory.
+	// 	"CompressedFileInfo-Before"  *FileInfo      Definition of each compressed file, up to its content.
+	// 	"CompressedFileInfo-After"   *FileInfo      Rest of the definition of each compressed file.
+	// 	"FileInfo-Before"            *FileInfo      Definition of each uncompressed file, up to its content.
+	// 	"FileInfo-After"             *FileInfo      Rest of the definition of each uncompressed file.
+	// 	"CompressedFileInfo-Shared"  *FileInfo      Definition of each compressed file with shared content.
+	// 	"FileInfo-Shared"            *FileInfo      Definition of each uncompressed file with shared content.
+	// 	"DirEntries"                 []*DirInfo     Directory entries and end of the variable.
+	// 	"SharedContent-Before"       *FileInfo      Declaration of each variable with shared content, up to the content.
+	// 	"SharedContent-After"        *FileInfo      Rest of the declaration of each variable with shared content.
+	// 	"Trailer"                    TOC            Implementation of the filesystem types.
+	// 	"PathConstants"              PathConstants  Path type, constants and accessors, if enabled.
+	// 	"Footer"                     TOC            Empty by default; executed at the end of the file.
+	//
+	// Files with the same content as other files are defined with the "-Shared" templates,
+	// referring to a variable named FileInfo.SharedContent that holds the content once.
+	// The variable is declared after the end of the http.FileSystem variable.
+	//
+	// The "quote" and "comment" functions are available to produce a Go string literal
+	// and a Go comment, respectively. Occurrences of "vfsgen۰" are replaced with TypePrefix.
+	Templates string
+
+	// StripPrefix is an optional path of a directory in the input filesystem.
+	// If set, only its contents are included in the generated code,
+	// with StripPrefix removed from the start of their paths.
+	// For example, with StripPrefix "/web/dist", the input file "/web/dist/index.html"
+	// is available at "/index.html" in the generated filesystem.
+	// When generating from multiple mounts, it applies to each of them.
+	StripPrefix string
+
+	// MountPrefix is an optional path that is added to the start of all paths
+	// in the generated filesystem, after StripPrefix is removed. Parent directories
+	// of MountPrefix are generated as needed, with a zero modification time.
+	// For example, with MountPrefix "/docs", the input file "/index.html"
+	// is available at "/docs/index.html" in the generated filesystem.
+	// When generating from multiple mounts, it's added before each Mount.At.
+	MountPrefix string
+
+	// Include is an optional list of patterns. If it's not empty, only files
+	// that match at least one of the patterns are included in the generated code,
+	// along with the directories that contain them. Directories without any
+	// included files, at any depth, are left out.
+	//
+	// Patterns are slash-separated and use path.Match syntax, with the addition
+	// that a "**" element matches zero or more path elements. A pattern without
+	// a slash matches the file name at any depth, otherwise it's matched against
+	// the full path relative to the root of the input filesystem.
+	// Patterns are matched against input paths, before StripPrefix and MountPrefix are applied.
+	Include []string
+
+	// Exclude is an optional list of patterns, in the same format as Include.
+	// Files and directories that match any of them are left out of the generated code,
+	// including all contents of excluded directories. Exclude takes precedence over Include.
+	Exclude []string
+
+	// Conflict specifies how GenerateMounts resolves paths that are provided by more
+	// than one mount. The zero value is ConflictError.
+	Conflict Conflict
+
+	// ModTime specifies the modification times of files and directories in the
+	// generated code. The zero value is ModTimeKeep.
+	ModTime ModTimePolicy
+
+	// IgnoreFile enables reading a ".vfsgenignore" file from the root of each input filesystem.
+	// It uses gitignore syntax, and paths it matches are left out of the generated code,
+	// as with Exclude. The ignore file itself is not included in the generated code.
+	IgnoreFile bool
+
+	// Budget optionally limits the size of the content stored in the generated code.
+	// If it's exceeded, Generate returns a *BudgetError that lists the offending
+	// files and their sizes, and nothing is written.
+	Budget Budget
 }
 
+// ModTimePolicy specifies the modification times of files and directories in the generated code.
+type ModTimePolicy int
+
+const (
+	// ModTimeKeep keeps the modification times of the input.
+	ModTimeKeep ModTimePolicy = iota
+
+	// ModTimeZero makes all modification times zero, so that the generated code
+	// depends only on the contents of the input, not on when it was last written.
+	ModTimeZero
+)
+
 // fillMissing sets default values for mandatory options that are left empty.
 func (opt *Options) fillMissing() {
 	if opt.PackageName == "" {
@@ -39,6 +181,12 @@ func (opt *Options) fillMissing() {
 	if opt.Filename == "" {
 		opt.Filename = fmt.Sprintf("%s_vfsdata.go", strings.ToLower(opt.VariableName))
 	}
+	if opt.TypePrefix == "" {
+		opt.TypePrefix = defaultTypePrefix
+	}
+	if opt.CompressionLevel == 0 {
+		opt.CompressionLevel = gzip.BestCompression
+	}
 	if opt.VariableComment == "" {
 		opt.VariableComment = fmt.Sprintf("%s statically implements the virtual filesystem provided to vfsgen.", opt.VariableName)
 	}
@@ -0,0 +1,66 @@
+package vfsgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PathConstants is the data of the "PathConstants" template.
+type PathConstants struct {
+	VariableName string         // Name of the http.FileSystem variable.
+	Prefix       string         // Exported form of VariableName, used to name the path type and accessors.
+	Paths        []PathConstant // Sorted by path. The root directory is not included.
+}
+
+// PathConstant is a constant for the path of a file or directory.
+type PathConstant struct {
+	Name    string
+	Padding string // Spaces that align the constant with others of different name lengths, as gofmt does.
+	Path    string
+}
+
+// newPathConstants returns path constants for all files and directories in toc.
+// It returns an error if different paths map to the same constant name.
+func newPathConstants(opt Options, toc TOC) (PathConstants, error) {
+	r, size := utf8.DecodeRuneInString(opt.VariableName)
+	pc := PathConstants{
+		VariableName: opt.VariableName,
+		Prefix:       string(unicode.ToUpper(r)) + opt.VariableName[size:],
+	}
+	var paths []string
+	for _, dir := range toc.Dirs {
+		if dir.Path != "/" {
+			paths = append(paths, dir.Path)
+		}
+	}
+	for _, file := range toc.Files {
+		paths = append(paths, file.Path)
+	}
+	sort.Strings(paths)
+
+	names := make(map[string]string) // Constant name -> path.
+	var maxLen int
+	for _, path := range paths {
+		name := pc.Prefix + "Path_" + strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+				return r
+			}
+			return '_'
+		}, strings.TrimPrefix(path, "/"))
+		if other, ok := names[name]; ok {
+			return PathConstants{}, fmt.Errorf("paths %q and %q have the same constant name %s", other, path, name)
+		}
+		names[name] = path
+		pc.Paths = append(pc.Paths, PathConstant{Name: name, Path: path})
+		if n := utf8.RuneCountInString(name); n > maxLen {
+			maxLen = n
+		}
+	}
+	for i := range pc.Paths {
+		pc.Paths[i].Padding = strings.Repeat(" ", maxLen-utf8.RuneCountInString(pc.Paths[i].Name))
+	}
+	return pc, nil
+}
@@ -0,0 +1,256 @@
+package vfsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	pathpkg "path"
+	"strings"
+)
+
+// Transform modifies the content of a file before it's included in the generated code.
+// It's called with the path of the file in the generated filesystem and a reader of
+// its content, and returns a reader of the new content. A Transform that doesn't apply
+// to the given file should return r as is.
+type Transform func(path string, r io.Reader) (io.Reader, error)
+
+// transform applies transforms to the content of the file at path in order,
+// and returns the resulting content.
+func transform(path string, r io.Reader, transforms []Transform) ([]byte, error) {
+	for _, tr := range transforms {
+		var err error
+		r, err = tr(path, r)
+		if err != nil {
+			return nil, fmt.Errorf("transforming %s: %v", path, err)
+		}
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("transforming %s: %v", path, err)
+	}
+	return b, nil
+}
+
+// minifier returns a Transform that applies minify to files with one of the given extensions.
+func minifier(minify func([]byte) ([]byte, error), exts ...string) Transform {
+	return func(path string, r io.Reader) (io.Reader, error) {
+		ext := strings.ToLower(pathpkg.Ext(path))
+		for _, e := range exts {
+			if ext != e {
+				continue
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			b, err = minify(b)
+			if err != nil {
+				return nil, err
+			}
+			return bytes.NewReader(b), nil
+		}
+		return r, nil
+	}
+}
+
+var (
+	// MinifyJSON is a Transform that removes insignificant whitespace
+	// from files with a ".json" extension. Invalid JSON is an error.
+	MinifyJSON = minifier(minifyJSON, ".json")
+
+	// MinifyCSS is a Transform that removes comments and unnecessary whitespace
+	// from files with a ".css" extension.
+	MinifyCSS = minifier(minifyCSS, ".css")
+
+	// MinifyHTML is a Transform that collapses runs of whitespace in the text
+	// of files with a ".html" or ".htm" extension. The contents of pre, textarea,
+	// script and style elements, tags and comments are left unchanged.
+	MinifyHTML = minifier(func(b []byte) ([]byte, error) {
+		return collapseMarkup(b, htmlRawElements, false), nil
+	}, ".html", ".htm")
+
+	// MinifySVG is a Transform that removes whitespace between tags and collapses
+	// other runs of whitespace in files with a ".svg" extension. The contents of
+	// text, script and style elements, tags and comments are left unchanged.
+	MinifySVG = minifier(func(b []byte) ([]byte, error) {
+		return collapseMarkup(b, svgRawElements, true), nil
+	}, ".svg")
+)
+
+func minifyJSON(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, b)
+	return buf.Bytes(), err
+}
+
+func minifyCSS(b []byte) ([]byte, error) {
+	// noSpaceBefore and noSpaceAfter are characters next to which whitespace isn't significant.
+	// ':' is not in noSpaceBefore because "a :hover" and "a:hover" are different selectors.
+	const (
+		noSpaceBefore = "{};,>)"
+		noSpaceAfter  = "{};,>:("
+	)
+	var out []byte
+	var space bool // Pending whitespace.
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end == -1 {
+				i = len(b)
+			} else {
+				i += 2 + end + 1
+			}
+			space = true
+			continue
+		case isSpace(c):
+			space = true
+			continue
+		}
+		if space && len(out) > 0 &&
+			!strings.ContainsRune(noSpaceAfter, rune(out[len(out)-1])) &&
+			!strings.ContainsRune(noSpaceBefore, rune(c)) {
+			out = append(out, ' ')
+		}
+		space = false
+		if c == '}' && len(out) > 0 && out[len(out)-1] == ';' {
+			out = out[:len(out)-1]
+		}
+		if c == '"' || c == '\'' {
+			// Copy the string as is, including escaped quotes.
+			j := i + 1
+			for ; j < len(b) && b[j] != c; j++ {
+				if b[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(b) {
+				j = len(b) - 1
+			}
+			out = append(out, b[i:j+1]...)
+			i = j
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+var (
+	htmlRawElements = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+	svgRawElements  = map[string]bool{"text": true, "script": true, "style": true}
+)
+
+// collapseMarkup collapses runs of whitespace in the text of HTML or XML markup b
+// to a single space, or a single newline if the run contains one. If trimBetweenTags
+// is true, text that consists only of whitespace is removed. Tags, comments and the
+// contents of raw elements are copied as is.
+func collapseMarkup(b []byte, raw map[string]bool, trimBetweenTags bool) []byte {
+	var out bytes.Buffer
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '<')
+		if i == -1 {
+			i = len(b)
+		}
+		writeCollapsed(&out, b[:i], trimBetweenTags)
+		b = b[i:]
+		if len(b) == 0 {
+			break
+		}
+
+		if bytes.HasPrefix(b, []byte("<!--")) {
+			end := bytes.Index(b, []byte("-->"))
+			if end == -1 {
+				end = len(b)
+			} else {
+				end += len("-->")
+			}
+			out.Write(b[:end])
+			b = b[end:]
+			continue
+		}
+
+		tag := b[:tagEnd(b)]
+		out.Write(tag)
+		b = b[len(tag):]
+		if name := tagName(tag); raw[name] && !bytes.HasSuffix(tag, []byte("/>")) {
+			end := indexEndTag(b, name)
+			out.Write(b[:end])
+			b = b[end:]
+		}
+	}
+	return out.Bytes()
+}
+
+// writeCollapsed writes text to out with runs of whitespace collapsed.
+func writeCollapsed(out *bytes.Buffer, text []byte, trimBetweenTags bool) {
+	if trimBetweenTags && len(bytes.TrimLeft(text, " \t\r\n\f")) == 0 {
+		return
+	}
+	for i := 0; i < len(text); i++ {
+		if !isSpace(text[i]) {
+			out.WriteByte(text[i])
+			continue
+		}
+		sep := byte(' ')
+		for ; i < len(text) && isSpace(text[i]); i++ {
+			if text[i] == '\n' {
+				sep = '\n'
+			}
+		}
+		i--
+		out.WriteByte(sep)
+	}
+}
+
+// tagEnd returns the index just past the '>' that ends the tag at the start of b,
+// ignoring any '>' in quoted attribute values.
+func tagEnd(b []byte) int {
+	var quote byte
+	for i := 1; i < len(b); i++ {
+		switch c := b[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// tagName returns the lower case name of start tag tag, or "" if it's not a start tag.
+func tagName(tag []byte) string {
+	i := 1
+	for ; i < len(tag); i++ {
+		c := tag[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == ':') {
+			break
+		}
+	}
+	return strings.ToLower(string(tag[1:i]))
+}
+
+// indexEndTag returns the index of the end tag of element name in b, or len(b) if there is none.
+func indexEndTag(b []byte, name string) int {
+	for off := 0; ; {
+		i := bytes.Index(b[off:], []byte("</"))
+		if i == -1 {
+			return len(b)
+		}
+		i += off
+		if end := i + 2 + len(name); end <= len(b) && strings.EqualFold(string(b[i+2:end]), name) {
+			return i
+		}
+		off = i + 2
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
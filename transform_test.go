@@ -0,0 +1,90 @@
+package vfsgen
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		transform Transform
+		path      string
+		in        string
+		want      string
+	}{
+		{
+			transform: MinifyJSON,
+			path:      "/data.json",
+			in:        "{\n\t\"a\": [1, 2],\n\t\"b\": \"x y\"\n}\n",
+			want:      `{"a":[1,2],"b":"x y"}`,
+		},
+		{
+			transform: MinifyJSON,
+			path:      "/data.txt",
+			in:        "{\n\t\"a\": 1\n}\n",
+			want:      "{\n\t\"a\": 1\n}\n",
+		},
+		{
+			transform: MinifyCSS,
+			path:      "/app.css",
+			in: `/* Comment. */
+a :hover, b > c {
+	color: red;
+	content: "a  ;  b";
+	width: calc(100% - 2px);
+}
+@media screen and (max-width: 100px) {
+	p { margin: 0 auto; }
+}
+`,
+			want: `a :hover,b>c{color:red;content:"a  ;  b";width:calc(100% - 2px)}@media screen and (max-width:100px){p{margin:0 auto}}`,
+		},
+		{
+			transform: MinifyHTML,
+			path:      "/index.HTML",
+			in: `<!DOCTYPE html>
+<html>
+	<body   class="a  b">
+		<p>Hello,   <b>world</b> !</p>
+		<pre>  keep
+   this  </pre>
+		<!--  comment  -->
+	</body>
+</html>
+`,
+			want: "<!DOCTYPE html>\n<html>\n<body   class=\"a  b\">\n<p>Hello, <b>world</b> !</p>\n<pre>  keep\n   this  </pre>\n<!--  comment  -->\n</body>\n</html>\n",
+		},
+		{
+			transform: MinifySVG,
+			path:      "/logo.svg",
+			in: `<svg xmlns="http://www.w3.org/2000/svg">
+	<g>
+		<path d="M 0 0 L 1 1"/>
+		<text>  Hello  there </text>
+	</g>
+</svg>
+`,
+			want: `<svg xmlns="http://www.w3.org/2000/svg"><g><path d="M 0 0 L 1 1"/><text>  Hello  there </text></g></svg>`,
+		},
+	}
+	for _, tc := range tests {
+		r, err := tc.transform(tc.path, strings.NewReader(tc.in))
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s:\ngot:  %q\nwant: %q", tc.path, got, tc.want)
+		}
+	}
+
+	_, err := MinifyJSON("/invalid.json", strings.NewReader("{"))
+	if err == nil {
+		t.Error("MinifyJSON: got nil error for invalid JSON, want non-nil")
+	}
+}
@@ -0,0 +1,200 @@
+package vfsgen
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+// node is a file or directory in the generated filesystem.
+type node struct {
+	path string      // Path in the generated filesystem.
+	fi   os.FileInfo // Nil for synthetic parent directories.
+
+	fs      http.FileSystem // Source filesystem of a file.
+	srcPath string          // Path of a file in fs.
+
+	entries map[string]*node // Directory entries by name. Nil for files.
+}
+
+func newDir(path string) *node {
+	return &node{path: path, entries: make(map[string]*node)}
+}
+
+func (n *node) isDir() bool { return n.entries != nil }
+
+// sortedEntries returns the directory entries of n sorted by name.
+func (n *node) sortedEntries() []*node {
+	names := make([]string, 0, len(n.entries))
+	for name := range n.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]*node, len(names))
+	for i, name := range names {
+		entries[i] = n.entries[name]
+	}
+	return entries
+}
+
+// tree is the generated filesystem, assembled from one or more mounts.
+type tree struct {
+	root     *node
+	conflict Conflict
+}
+
+func newTree(conflict Conflict) *tree {
+	return &tree{root: newDir("/"), conflict: conflict}
+}
+
+// add adds n at path, creating synthetic parent directories as needed.
+// Directories that already exist are merged with n. Other paths that
+// already exist are resolved according to t.conflict. It returns the node
+// now at path, or nil if n was discarded in favor of an existing entry.
+func (t *tree) add(path string, n *node) (*node, error) {
+	if path == "/" {
+		return t.mergeDir(t.root, n), nil
+	}
+	parent := t.root
+	for _, name := range strings.Split(strings.TrimPrefix(pathpkg.Dir(path), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		dir, ok := parent.entries[name]
+		if ok && !dir.isDir() {
+			switch t.conflict {
+			case ConflictFirstWins:
+				return nil, nil
+			case ConflictLastWins:
+				ok = false
+			default:
+				return nil, &os.PathError{Op: "mount", Path: dir.path, Err: errConflict}
+			}
+		}
+		if !ok {
+			dir = newDir(pathpkg.Join(parent.path, name))
+			parent.entries[name] = dir
+		}
+		parent = dir
+	}
+
+	name := pathpkg.Base(path)
+	existing, ok := parent.entries[name]
+	switch {
+	case !ok:
+		parent.entries[name] = n
+		return n, nil
+	case existing.isDir() && n.isDir():
+		return t.mergeDir(existing, n), nil
+	}
+	switch t.conflict {
+	case ConflictFirstWins:
+		return nil, nil
+	case ConflictLastWins:
+		parent.entries[name] = n
+		return n, nil
+	default:
+		return nil, &os.PathError{Op: "mount", Path: path, Err: errConflict}
+	}
+}
+
+// mergeDir merges directory n into existing directory dir and returns dir.
+// Synthetic directories take on the file info of n.
+func (t *tree) mergeDir(dir, n *node) *node {
+	if dir.fi == nil || t.conflict == ConflictLastWins {
+		dir.fi = n.fi
+	}
+	return dir
+}
+
+var errConflict = errors.New("path exists in more than one mount")
+
+// prune removes the directories in directory n that have no files, at any depth,
+// and reports whether n has no files.
+func (n *node) prune() bool {
+	empty := true
+	for name, e := range n.entries {
+		if e.isDir() && e.prune() {
+			delete(n.entries, name)
+			continue
+		}
+		empty = false
+	}
+	return empty
+}
+
+// findAllFiles returns the tree of all the files and directories in the given
+// mounts that are kept by their filters. If opt.Include is set, directories
+// without any files that it includes are left out.
+func findAllFiles(mounts []Mount, opt Options) (*tree, error) {
+	t := newTree(opt.Conflict)
+	for _, m := range mounts {
+		f, err := newFilter(m.FS, opt)
+		if err != nil {
+			return nil, err
+		}
+		err = findFiles(t, m, opt, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(opt.Include) > 0 {
+		t.root.prune()
+	}
+	return t, nil
+}
+
+// findFiles walks the filesystem of mount m and adds all the files and directories
+// that are kept by filter f to tree t. The subtree at opt.StripPrefix in m.FS
+// is placed at m.At within opt.MountPrefix in the tree.
+func findFiles(t *tree, m Mount, opt Options, f *filter) error {
+	src := pathpkg.Clean("/" + opt.StripPrefix)
+	fi, err := vfsutil.Stat(m.FS, src)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+	at := pathpkg.Join("/", opt.MountPrefix, m.At)
+
+	walkFn := func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// Consider all errors reading the input filesystem as fatal.
+			return err
+		}
+
+		if !f.keep(path, fi.IsDir()) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dst := pathpkg.Join(at, strings.TrimPrefix(path, src))
+		n := &node{path: dst, fi: fi}
+		if fi.IsDir() {
+			n.entries = make(map[string]*node)
+		} else {
+			n.fs, n.srcPath = m.FS, path
+		}
+		added, err := t.add(dst, n)
+		if err != nil {
+			return err
+		}
+		if added == nil && fi.IsDir() {
+			// An existing entry took precedence, so skip the contents of this directory.
+			return filepath.SkipDir
+		}
+		return nil
+	}
+
+	return vfsutil.Walk(m.FS, src, walkFn)
+}
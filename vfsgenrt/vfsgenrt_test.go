@@ -0,0 +1,106 @@
+package vfsgenrt_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/shurcooL/vfsgen/vfsgenrt"
+)
+
+// newFS returns a filesystem like the ones generated by vfsgen with RuntimeImport set.
+func newFS() http.FileSystem {
+	content := "This file compresses well. " + strings.Repeat("Blah", 16) + "!"
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	io.WriteString(gw, content)
+	gw.Close()
+
+	fs := vfsgenrt.FS{
+		"/": &vfsgenrt.DirInfo{
+			BaseName: "/",
+		},
+		"/compressed.txt": &vfsgenrt.CompressedFileInfo{
+			BaseName:          "compressed.txt",
+			UncompressedSize:  int64(len(content)),
+			CompressedContent: buf.Bytes(),
+		},
+		"/plain.txt": &vfsgenrt.FileInfo{
+			BaseName: "plain.txt",
+			Content:  []byte("Plain."),
+		},
+	}
+	fs["/"].(*vfsgenrt.DirInfo).Entries = []os.FileInfo{
+		fs["/compressed.txt"].(os.FileInfo),
+		fs["/plain.txt"].(os.FileInfo),
+	}
+	return fs
+}
+
+func Example() {
+	fs := newFS()
+
+	d, err := fs.Open("/")
+	if err != nil {
+		panic(err)
+	}
+	defer d.Close()
+	fis, err := d.Readdir(0)
+	if err != nil {
+		panic(err)
+	}
+	for _, fi := range fis {
+		f, err := fs.Open("//" + fi.Name())
+		if err != nil {
+			panic(err)
+		}
+		b, err := io.ReadAll(f)
+		f.Close()
+		_, compressed := f.(vfsgenrt.GzipByter)
+		fmt.Printf("%s %d %q %v compressed=%v\n", fi.Name(), fi.Size(), b, err, compressed)
+	}
+
+	_, err = fs.Open("/does-not-exist")
+	fmt.Println(err)
+
+	// Output:
+	// compressed.txt 92 "This file compresses well. BlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlahBlah!" <nil> compressed=true
+	// plain.txt 6 "Plain." <nil> compressed=false
+	// open /does-not-exist: file does not exist
+}
+
+func ExampleCompressedFile_Seek() {
+	f, err := newFS().Open("/compressed.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(os.Stdout, f, 5)
+	if err != nil {
+		panic(err)
+	}
+	_, err = f.Seek(-5, io.SeekEnd)
+	if err != nil {
+		panic(err)
+	}
+	_, err = io.Copy(os.Stdout, f)
+	if err != nil {
+		panic(err)
+	}
+	_, err = f.Seek(5, io.SeekStart)
+	if err != nil {
+		panic(err)
+	}
+	_, err = io.CopyN(os.Stdout, f, 5)
+	if err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// This Blah!file
+}
@@ -0,0 +1,132 @@
+package vfsgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+// Watch generates code for input like Generate, and then polls input every interval,
+// generating code again whenever a file or directory is added or removed, or the
+// size, mode or modification time of a file changes. Only paths kept by Include,
+// Exclude and the ignore file are watched, along with the ignore file itself,
+// and the output files are not. A change is acted upon once input is the same
+// for two polls in a row, so that a burst of changes results in a single generation.
+//
+// Generation and polling errors are logged, and watching continues. A warning is
+// logged when the generated filesystem has no files, such as when filters exclude
+// all of them. Watch returns when ctx is done, with the error from ctx. It returns
+// an error without generating code if interval is not positive.
+func Watch(ctx context.Context, input http.FileSystem, opt Options, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval %v is not positive", interval)
+	}
+	opt.fillMissing()
+	generate := func() {
+		start := time.Now()
+		toc, err := generateMounts([]Mount{{At: "/", FS: input}}, opt)
+		if err != nil {
+			log.Printf("vfsgen: generating %s: %v", opt.Filename, err)
+			return
+		}
+		log.Printf("vfsgen: generated %s in %v", opt.Filename, time.Since(start).Round(time.Millisecond))
+		if len(toc.Files) == 0 {
+			log.Printf("vfsgen: warning: %s has no files", opt.Filename)
+		}
+	}
+
+	// Take the fingerprint before generating, so that a change made while
+	// generating is picked up by the next poll.
+	last, err := fingerprint(input, opt) // Fingerprint of input as of the last generation.
+	if err != nil {
+		log.Printf("vfsgen: watching %s: %v", opt.Filename, err)
+	}
+	generate()
+
+	var pending *[sha256.Size]byte // Fingerprint of a change waiting for input to settle.
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		fp, err := fingerprint(input, opt)
+		if err != nil {
+			log.Printf("vfsgen: watching %s: %v", opt.Filename, err)
+			continue
+		}
+		switch {
+		case fp == last:
+			pending = nil
+		case pending == nil || *pending != fp:
+			// Input changed; wait until it's the same for another poll.
+			pending = &fp
+		default:
+			log.Printf("vfsgen: input of %s changed", opt.Filename)
+			last, pending = fp, nil
+			generate()
+		}
+	}
+}
+
+// fingerprint returns a hash of the path and mode of every file and directory
+// in input that's kept by the filters in opt, and the size and modification time
+// of every such file, and of the ignore file if opt.IgnoreFile is set. The output files in opt are left out,
+// so that writing them doesn't look like a change when they're inside input.
+func fingerprint(input http.FileSystem, opt Options) ([sha256.Size]byte, error) {
+	var fp [sha256.Size]byte
+	t, err := findAllFiles([]Mount{{At: "/", FS: input}}, opt)
+	if err != nil {
+		return fp, err
+	}
+	var outputs []os.FileInfo
+	for _, name := range []string{opt.Filename, opt.ManifestFilename} {
+		if fi, err := os.Stat(name); name != "" && err == nil {
+			outputs = append(outputs, fi)
+		}
+	}
+
+	h := sha256.New()
+	var walk func(n *node)
+	walk = func(n *node) {
+		switch {
+		case n.fi == nil || isOutput(n.fi, outputs):
+		case n.isDir():
+			// Changes to the entries of a directory show up as their paths, while its size
+			// and modification time also change when output files are created in it.
+			fmt.Fprintf(h, "%q %v\n", n.path, n.fi.Mode())
+		default:
+			fmt.Fprintf(h, "%q %d %v %d\n", n.path, n.fi.Size(), n.fi.Mode(), n.fi.ModTime().UnixNano())
+		}
+		for _, e := range n.sortedEntries() {
+			walk(e)
+		}
+	}
+	walk(t.root)
+	if opt.IgnoreFile {
+		if fi, err := vfsutil.Stat(input, "/"+ignoreFilename); err == nil {
+			fmt.Fprintf(h, "%q %d %v %d\n", ignoreFilename, fi.Size(), fi.Mode(), fi.ModTime().UnixNano())
+		}
+	}
+	h.Sum(fp[:0])
+	return fp, nil
+}
+
+// isOutput reports whether fi describes one of the output files.
+func isOutput(fi os.FileInfo, outputs []os.FileInfo) bool {
+	for _, o := range outputs {
+		if os.SameFile(fi, o) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package vfsgen
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/httpfs/vfsutil"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("file.txt", "Hello.")
+	write("file.txt~", "Backup.")
+	write(".vfsgenignore", ".DS_Store\n")
+	opt := Options{
+		Filename:   filepath.Join(dir, "assets_vfsdata.go"),
+		Exclude:    []string{"*~"},
+		IgnoreFile: true,
+	}
+	opt.fillMissing()
+	last, err := fingerprint(http.Dir(dir), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name, content string
+		wantChange    bool
+	}{
+		{name: "file.txt~", content: "Changed backup.", wantChange: false},
+		{name: ".DS_Store", content: "Finder.", wantChange: false},
+		{name: "assets_vfsdata.go", content: "package main", wantChange: false},
+		{name: ".vfsgenignore", content: ".DS_Store\n*.tmp\n", wantChange: true},
+		{name: "file.txt", content: "Hello, world.", wantChange: true},
+	} {
+		write(tc.name, tc.content)
+		fp, err := fingerprint(http.Dir(dir), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fp != last; got != tc.wantChange {
+			t.Errorf("writing %s: got fingerprint change %v, want %v", tc.name, got, tc.wantChange)
+		}
+		last = fp
+	}
+}
+
+func TestWatch(t *testing.T) {
+	inputDir, outputDir := t.TempDir(), t.TempDir()
+	err := os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte("before"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(outputDir, "assets_vfsdata.go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, http.Dir(inputDir), Options{Filename: filename}, 10*time.Millisecond)
+	}()
+	waitForOutput := func(want string) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			fs, err := LoadGenerated(filename)
+			if err != nil {
+				continue
+			}
+			if b, err := vfsutil.ReadFile(fs, "/file.txt"); err == nil && string(b) == want {
+				return
+			}
+		}
+		t.Fatalf("output doesn't contain file with content %q", want)
+	}
+	waitForOutput("before")
+
+	err = os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte("after, with a new size"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput("after, with a new size")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got Watch error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWatch_interval(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := Watch(context.Background(), http.Dir(t.TempDir()), Options{Filename: filename}, interval)
+		if err == nil {
+			t.Errorf("interval %v: got nil error, want non-nil", interval)
+		}
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("got output file written with invalid interval: %v", err)
+	}
+}
+
+func TestWatch_noFiles(t *testing.T) {
+	inputDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte("Hello."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// The input has a file, but it's excluded from the generated filesystem.
+	filename := filepath.Join(t.TempDir(), "assets_vfsdata.go")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, http.Dir(inputDir), Options{Filename: filename, Include: []string{"*.html"}}, time.Hour)
+	}()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(filename); err == nil {
+			break
+		}
+	}
+	// The first generation is done before Watch waits for ctx.
+	cancel()
+	<-done
+
+	if want := "vfsgen: warning: " + filename + " has no files"; !strings.Contains(buf.String(), want) {
+		t.Errorf("got log output %q, want it to contain %q", buf.String(), want)
+	}
+}